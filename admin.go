@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Specialized101/chirpy/internal/auth"
+	"github.com/Specialized101/chirpy/internal/database"
+	"github.com/Specialized101/chirpy/internal/handle"
+	"github.com/google/uuid"
+)
+
+// userJSON is the -json output of the user commands. It matches the users
+// returned by the API.
+type userJSON struct {
+	ID          uuid.UUID `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Email       string    `json:"email"`
+	Username    *string   `json:"username"`
+	Role        string    `json:"role"`
+	IsChirpyRed bool      `json:"is_chirpy_red"`
+}
+
+func newUserJSON(user database.User) userJSON {
+	return userJSON{
+		ID:          user.ID,
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+		Email:       user.Email,
+		Username:    nullableString(user.Username),
+		Role:        user.Role,
+		IsChirpyRed: user.IsChirpyRed,
+	}
+}
+
+func setupUserCreate(fs *flag.FlagSet) runFunc {
+	usernameFlag := fs.String("username", "", "username of the user")
+	roleFlag := fs.String("role", string(auth.RoleUser), "role of the user: user, moderator or admin")
+	return func(ctx context.Context, c *cli, args []string) error {
+		if err := exactArgs(args, 1, "user create [flags] <email>"); err != nil {
+			return err
+		}
+		email := strings.TrimSpace(args[0])
+		if email == "" {
+			return errors.New("email is required")
+		}
+		role, err := auth.ParseRole(*roleFlag)
+		if err != nil {
+			return err
+		}
+		username := sql.NullString{}
+		if *usernameFlag != "" {
+			if err := handle.Validate(*usernameFlag); err != nil {
+				return err
+			}
+			username = sql.NullString{String: *usernameFlag, Valid: true}
+		}
+		if _, err := c.queries.GetUserByEmail(ctx, email); err == nil {
+			return fmt.Errorf("user %q already exists", email)
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		password, err := c.readPassword()
+		if err != nil {
+			return err
+		}
+		hashedPwd, err := auth.HashPassword(password)
+		if err != nil {
+			return fmt.Errorf("failed to hash the password: %w", err)
+		}
+		user, err := c.queries.CreateUser(ctx, database.CreateUserParams{
+			ID:             uuid.New(),
+			CreatedAt:      time.Now().UTC(),
+			UpdatedAt:      time.Now().UTC(),
+			Email:          email,
+			HashedPassword: hashedPwd,
+			Username:       username,
+		})
+		if usernameTaken(err) {
+			return fmt.Errorf("username %q is already taken", username.String)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+		if role != auth.RoleUser {
+			user, err = c.queries.SetUserRole(ctx, database.SetUserRoleParams{ID: user.ID, Role: string(role)})
+			if err != nil {
+				return fmt.Errorf("failed to set the role: %w", err)
+			}
+		}
+		return c.print(newUserJSON(user), "created %s %s (%s)", user.Role, user.Email, user.ID)
+	}
+}
+
+func runUserGrantRed(ctx context.Context, c *cli, args []string) error {
+	if err := exactArgs(args, 1, "user grant-red [flags] <email|id|@username>"); err != nil {
+		return err
+	}
+	user, err := c.findUser(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	user, err = c.queries.UpgradeUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to upgrade user: %w", err)
+	}
+	return c.print(newUserJSON(user), "user %s (%s) is now Chirpy Red", user.Email, user.ID)
+}
+
+// runUserSetRole is how the first admin is made, the API only lets admins
+// change roles.
+func runUserSetRole(ctx context.Context, c *cli, args []string) error {
+	if err := exactArgs(args, 2, "user set-role [flags] <email|id|@username> <role>"); err != nil {
+		return err
+	}
+	role, err := auth.ParseRole(args[1])
+	if err != nil {
+		return err
+	}
+	user, err := c.findUser(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	user, err = c.queries.SetUserRole(ctx, database.SetUserRoleParams{ID: user.ID, Role: string(role)})
+	if err != nil {
+		return fmt.Errorf("failed to set the role: %w", err)
+	}
+	return c.print(newUserJSON(user), "user %s (%s) is now %s", user.Email, user.ID, user.Role)
+}
+
+// runUserResetPassword also revokes every refresh token of the user, whoever
+// knew the old password is signed out.
+func runUserResetPassword(ctx context.Context, c *cli, args []string) error {
+	if err := exactArgs(args, 1, "user reset-password [flags] <email|id|@username>"); err != nil {
+		return err
+	}
+	user, err := c.findUser(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	password, err := c.readPassword()
+	if err != nil {
+		return err
+	}
+	hashedPwd, err := auth.HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("failed to hash the password: %w", err)
+	}
+	user, err = c.queries.UpdateUser(ctx, database.UpdateUserParams{
+		Email:          user.Email,
+		HashedPassword: hashedPwd,
+		ID:             user.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	if err := c.queries.RevokeUserRefreshTokens(ctx, user.ID); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return c.print(newUserJSON(user), "reset the password of %s (%s) and signed them out", user.Email, user.ID)
+}
+
+// setupTokenRevoke revokes the session a refresh token belongs to, so the
+// tokens it was rotated into are revoked too, or every session of a user.
+func setupTokenRevoke(fs *flag.FlagSet) runFunc {
+	userFlag := fs.String("user", "", "revoke every refresh token of this user (email, id or @username) instead")
+	return func(ctx context.Context, c *cli, args []string) error {
+		type result struct {
+			UserID   uuid.UUID  `json:"user_id"`
+			FamilyID *uuid.UUID `json:"family_id,omitempty"`
+		}
+		if *userFlag != "" {
+			if err := exactArgs(args, 0, "token revoke -user <email|id|@username>"); err != nil {
+				return err
+			}
+			user, err := c.findUser(ctx, *userFlag)
+			if err != nil {
+				return err
+			}
+			if err := c.queries.RevokeUserRefreshTokens(ctx, user.ID); err != nil {
+				return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+			}
+			return c.print(result{UserID: user.ID}, "revoked every refresh token of %s (%s)", user.Email, user.ID)
+		}
+
+		if err := exactArgs(args, 1, "token revoke [flags] <refresh-token>"); err != nil {
+			return err
+		}
+		rt, err := c.queries.GetRefreshToken(ctx, args[0])
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("refresh token not found")
+		}
+		if err != nil {
+			return err
+		}
+		if err := c.queries.RevokeRefreshTokenFamily(ctx, rt.FamilyID); err != nil {
+			return fmt.Errorf("failed to revoke refresh token: %w", err)
+		}
+		return c.print(result{UserID: rt.UserID, FamilyID: &rt.FamilyID}, "revoked session %s of user %s", rt.FamilyID, rt.UserID)
+	}
+}
+
+func runChirpDelete(ctx context.Context, c *cli, args []string) error {
+	if err := exactArgs(args, 1, "chirp delete [flags] <chirp-id>"); err != nil {
+		return err
+	}
+	chirpID, err := uuid.Parse(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid chirp id %q", args[0])
+	}
+	chirp, err := c.queries.GetChirpByID(ctx, chirpID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("chirp %s not found", chirpID)
+	}
+	if err != nil {
+		return err
+	}
+	if err := deleteChirp(ctx, c.queries, chirp.ID); err != nil {
+		return fmt.Errorf("failed to delete chirp: %w", err)
+	}
+	type result struct {
+		ID     uuid.UUID `json:"id"`
+		Body   string    `json:"body"`
+		UserID uuid.UUID `json:"user_id"`
+	}
+	return c.print(result{ID: chirp.ID, Body: chirp.Body, UserID: chirp.UserID}, "deleted chirp %s of user %s", chirp.ID, chirp.UserID)
+}
@@ -0,0 +1,1535 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Specialized101/chirpy/internal/auth"
+	"github.com/Specialized101/chirpy/internal/config"
+	"github.com/Specialized101/chirpy/internal/database"
+	"github.com/Specialized101/chirpy/internal/memstore"
+	"github.com/Specialized101/chirpy/internal/moderation"
+	"github.com/google/uuid"
+)
+
+type testAPI struct {
+	t       *testing.T
+	cfg     *apiConfig
+	handler http.Handler
+}
+
+func newTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+	defaults := config.Default()
+	cfg := &apiConfig{
+		db:              memstore.New(),
+		platform:        "dev",
+		secret:          "test secret",
+		polkaKey:        "test polka key",
+		accessTokenTTL:  defaults.Auth.AccessTokenTTL,
+		refreshTokenTTL: defaults.Auth.RefreshTokenTTL,
+		maxChirpLength:  defaults.Chirps.MaxLength,
+		chirpEditWindow: defaults.Chirps.EditWindow,
+		trendingWindow:  defaults.Chirps.TrendingWindow,
+		moderation:      moderation.NewFilter(nil),
+		logger:          newLogger(io.Discard),
+		metrics:         newMetrics(),
+	}
+	if err := cfg.loadModerationRules(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return &testAPI{t: t, cfg: cfg, handler: cfg.routes()}
+}
+
+// do sends a request through the router. token is sent as a bearer token
+// when it is not empty, body is encoded as JSON when it is not nil.
+func (a *testAPI) do(method, path, token string, body any) *httptest.ResponseRecorder {
+	a.t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			a.t.Fatalf("failed to encode body: %v", err)
+		}
+	}
+	r := httptest.NewRequest(method, path, &buf)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	a.handler.ServeHTTP(w, r)
+	return w
+}
+
+func (a *testAPI) expectStatus(w *httptest.ResponseRecorder, expected int) {
+	a.t.Helper()
+	if w.Code != expected {
+		a.t.Fatalf("expected status %d, received %d: %s", expected, w.Code, w.Body.String())
+	}
+}
+
+func decodeBody[T any](t *testing.T, w *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	if err := json.NewDecoder(w.Body).Decode(&v); err != nil {
+		t.Fatalf("failed to decode response %q: %v", w.Body.String(), err)
+	}
+	return v
+}
+
+type testUser struct {
+	ID           uuid.UUID `json:"id"`
+	Email        string    `json:"email"`
+	Token        string    `json:"token"`
+	RefreshToken string    `json:"refresh_token"`
+	IsChirpyRed  bool      `json:"is_chirpy_red"`
+}
+
+type testChirp struct {
+	ID     uuid.UUID `json:"id"`
+	Body   string    `json:"body"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+// signup creates a user and logs them in.
+func (a *testAPI) signup(email string) testUser {
+	a.t.Helper()
+	a.expectStatus(a.do("POST", "/api/users", "", map[string]string{
+		"email":    email,
+		"password": "password",
+	}), http.StatusCreated)
+	return a.login(email, "password")
+}
+
+// signupAs creates a user with a role and logs them in.
+func (a *testAPI) signupAs(email string, role auth.Role) testUser {
+	a.t.Helper()
+	user := a.signup(email)
+	_, err := a.cfg.db.SetUserRole(context.Background(), database.SetUserRoleParams{ID: user.ID, Role: string(role)})
+	if err != nil {
+		a.t.Fatalf("failed to set the role: %v", err)
+	}
+	return a.login(email, "password")
+}
+
+func (a *testAPI) login(email, password string) testUser {
+	a.t.Helper()
+	w := a.do("POST", "/api/login", "", map[string]string{
+		"email":    email,
+		"password": password,
+	})
+	a.expectStatus(w, http.StatusOK)
+	return decodeBody[testUser](a.t, w)
+}
+
+func (a *testAPI) chirp(user testUser, body string) testChirp {
+	a.t.Helper()
+	w := a.do("POST", "/api/chirps", user.Token, map[string]string{"body": body})
+	a.expectStatus(w, http.StatusCreated)
+	return decodeBody[testChirp](a.t, w)
+}
+
+// walk follows the Link headers from path and returns every item.
+func walk[T any](a *testAPI, path, token string) []T {
+	a.t.Helper()
+	var items []T
+	for path != "" {
+		w := a.do("GET", path, token, nil)
+		a.expectStatus(w, http.StatusOK)
+		items = append(items, decodeBody[[]T](a.t, w)...)
+		path = ""
+		if link := w.Header().Get("Link"); link != "" {
+			path = strings.TrimPrefix(strings.TrimSuffix(link, `>; rel="next"`), "<")
+		}
+	}
+	return items
+}
+
+func TestHealthz(t *testing.T) {
+	a := newTestAPI(t)
+	w := a.do("GET", "/api/healthz", "", nil)
+	a.expectStatus(w, http.StatusOK)
+	if w.Body.String() != "OK" {
+		t.Errorf("expected OK, received %q", w.Body.String())
+	}
+}
+
+func TestAppAndMetrics(t *testing.T) {
+	a := newTestAPI(t)
+	a.expectStatus(a.do("GET", "/app/", "", nil), http.StatusOK)
+	a.expectStatus(a.do("GET", "/app/assets/logo.png", "", nil), http.StatusOK)
+	admin := a.signupAs("admin@example.com", auth.RoleAdmin)
+	w := a.do("GET", "/admin/metrics", admin.Token, nil)
+	a.expectStatus(w, http.StatusOK)
+	if !strings.Contains(w.Body.String(), "visited 2 times") {
+		t.Errorf("expected 2 visits, received %q", w.Body.String())
+	}
+
+	w = a.do("GET", "/metrics", "", nil)
+	a.expectStatus(w, http.StatusOK)
+	for _, expected := range []string{
+		"chirpy_fileserver_hits 2",
+		`chirpy_http_requests_total{method="GET",route="GET /admin/metrics",status="200"} 1`,
+		`chirpy_http_request_duration_seconds_count{method="GET",route="/app/"} 2`,
+	} {
+		if !strings.Contains(w.Body.String(), expected) {
+			t.Errorf("expected %q in the exposition:\n%s", expected, w.Body.String())
+		}
+	}
+}
+
+func TestRefreshMetrics(t *testing.T) {
+	a := newTestAPI(t)
+	user := a.signup("metrics@example.com")
+	a.expectStatus(a.do("POST", "/api/refresh", "", nil), http.StatusUnauthorized)
+	a.expectStatus(a.do("POST", "/api/refresh", "unknown", nil), http.StatusUnauthorized)
+	w := a.do("POST", "/api/refresh", user.RefreshToken, nil)
+	a.expectStatus(w, http.StatusOK)
+	refreshed := decodeBody[testUser](t, w)
+	a.expectStatus(a.do("POST", "/api/refresh", user.RefreshToken, nil), http.StatusUnauthorized)
+	a.expectStatus(a.do("POST", "/api/refresh", refreshed.RefreshToken, nil), http.StatusUnauthorized)
+	a.cfg.refreshTokenTTL = -time.Minute
+	expired := a.login("metrics@example.com", "password")
+	a.expectStatus(a.do("POST", "/api/refresh", expired.RefreshToken, nil), http.StatusUnauthorized)
+
+	w = a.do("GET", "/metrics", "", nil)
+	a.expectStatus(w, http.StatusOK)
+	for _, expected := range []string{
+		`chirpy_token_refreshes_total{result="invalid"} 2`,
+		`chirpy_token_refreshes_total{result="success"} 1`,
+		`chirpy_token_refreshes_total{result="reuse_detected"} 1`,
+		`chirpy_token_refreshes_total{result="revoked"} 1`,
+		`chirpy_token_refreshes_total{result="expired"} 1`,
+	} {
+		if !strings.Contains(w.Body.String(), expected) {
+			t.Errorf("expected %q in the exposition:\n%s", expected, w.Body.String())
+		}
+	}
+}
+
+func TestAdminReset(t *testing.T) {
+	a := newTestAPI(t)
+	user := a.signup("reset@example.com")
+	admin := a.signupAs("admin@example.com", auth.RoleAdmin)
+	a.expectStatus(a.do("POST", "/admin/reset", user.Token, nil), http.StatusForbidden)
+	a.expectStatus(a.do("POST", "/admin/reset", admin.Token, nil), http.StatusOK)
+	a.expectStatus(a.do("POST", "/api/login", "", map[string]string{
+		"email":    user.Email,
+		"password": "password",
+	}), http.StatusUnauthorized)
+
+	// The reset deleted the admin too, their token no longer works.
+	a.expectStatus(a.do("POST", "/admin/reset", admin.Token, nil), http.StatusUnauthorized)
+	admin = a.signupAs("admin@example.com", auth.RoleAdmin)
+	a.cfg.platform = "prod"
+	a.expectStatus(a.do("POST", "/admin/reset", admin.Token, nil), http.StatusForbidden)
+}
+
+func TestCreateUser(t *testing.T) {
+	a := newTestAPI(t)
+	w := a.do("POST", "/api/users", "", map[string]string{"email": "a@example.com", "password": "password"})
+	a.expectStatus(w, http.StatusCreated)
+	if user := decodeBody[testUser](t, w); user.Email != "a@example.com" || user.IsChirpyRed {
+		t.Errorf("unexpected user: %+v", user)
+	}
+	a.expectStatus(a.do("POST", "/api/users", "", map[string]string{"password": "password"}), http.StatusBadRequest)
+	a.expectStatus(a.do("POST", "/api/users", "", map[string]string{"email": "b@example.com"}), http.StatusBadRequest)
+}
+
+func TestLogin(t *testing.T) {
+	a := newTestAPI(t)
+	user := a.signup("login@example.com")
+	if user.Token == "" || user.RefreshToken == "" {
+		t.Errorf("expected tokens, received %+v", user)
+	}
+	a.expectStatus(a.do("POST", "/api/login", "", map[string]string{
+		"email":    user.Email,
+		"password": "wrong",
+	}), http.StatusUnauthorized)
+	a.expectStatus(a.do("POST", "/api/login", "", map[string]string{
+		"email":    "nobody@example.com",
+		"password": "password",
+	}), http.StatusUnauthorized)
+}
+
+func TestUpdateUser(t *testing.T) {
+	a := newTestAPI(t)
+	user := a.signup("before@example.com")
+	body := map[string]string{"email": "after@example.com", "password": "new password"}
+	a.expectStatus(a.do("PUT", "/api/users", "", body), http.StatusUnauthorized)
+	w := a.do("PUT", "/api/users", user.Token, body)
+	a.expectStatus(w, http.StatusOK)
+	updated := decodeBody[map[string]any](t, w)
+	if updated["email"] != "after@example.com" {
+		t.Errorf("expected updated email, received %+v", updated)
+	}
+	// Refresh tokens belong to sessions, the update does not return one.
+	if _, ok := updated["refresh_token"]; ok {
+		t.Errorf("expected no refresh token, received %+v", updated)
+	}
+	a.expectStatus(a.do("POST", "/api/refresh", user.RefreshToken, nil), http.StatusOK)
+	a.login("after@example.com", "new password")
+}
+
+func TestRefreshRotation(t *testing.T) {
+	a := newTestAPI(t)
+	user := a.signup("refresh@example.com")
+
+	w := a.do("POST", "/api/refresh", user.RefreshToken, nil)
+	a.expectStatus(w, http.StatusOK)
+	rotated := decodeBody[testUser](t, w)
+	if rotated.Token == "" || rotated.RefreshToken == "" || rotated.RefreshToken == user.RefreshToken {
+		t.Fatalf("expected a new refresh token, received %+v", rotated)
+	}
+
+	// Presenting the retired token again revokes the whole family.
+	a.expectStatus(a.do("POST", "/api/refresh", user.RefreshToken, nil), http.StatusUnauthorized)
+	a.expectStatus(a.do("POST", "/api/refresh", rotated.RefreshToken, nil), http.StatusUnauthorized)
+
+	a.expectStatus(a.do("POST", "/api/refresh", "unknown", nil), http.StatusUnauthorized)
+	a.expectStatus(a.do("POST", "/api/refresh", "", nil), http.StatusUnauthorized)
+}
+
+func TestRefreshTokenFamilies(t *testing.T) {
+	a := newTestAPI(t)
+	phone := a.signup("families@example.com")
+	laptop := a.login("families@example.com", "password")
+	if phone.RefreshToken == laptop.RefreshToken {
+		t.Fatalf("expected each login to get its own refresh token")
+	}
+
+	refresh := func(token string, expected int) testUser {
+		t.Helper()
+		w := a.do("POST", "/api/refresh", token, nil)
+		a.expectStatus(w, expected)
+		if expected != http.StatusOK {
+			return testUser{}
+		}
+		return decodeBody[testUser](t, w)
+	}
+	first := refresh(phone.RefreshToken, http.StatusOK)
+	second := refresh(first.RefreshToken, http.StatusOK)
+
+	// Reusing a retired token of the phone's family revokes that family only.
+	refresh(first.RefreshToken, http.StatusUnauthorized)
+	refresh(second.RefreshToken, http.StatusUnauthorized)
+	laptop = refresh(laptop.RefreshToken, http.StatusOK)
+
+	// Logging in again after a revocation issues a new, working token.
+	a.expectStatus(a.do("POST", "/api/revoke", laptop.RefreshToken, nil), http.StatusNoContent)
+	again := a.login("families@example.com", "password")
+	if again.RefreshToken == laptop.RefreshToken {
+		t.Errorf("expected login not to reuse the revoked refresh token")
+	}
+	refresh(again.RefreshToken, http.StatusOK)
+
+	a.cfg.refreshTokenTTL = -time.Minute
+	expired := a.login("families@example.com", "password")
+	refresh(expired.RefreshToken, http.StatusUnauthorized)
+}
+
+func TestRevoke(t *testing.T) {
+	a := newTestAPI(t)
+	user := a.signup("revoke@example.com")
+	a.expectStatus(a.do("POST", "/api/revoke", "", nil), http.StatusUnauthorized)
+	a.expectStatus(a.do("POST", "/api/revoke", user.RefreshToken, nil), http.StatusNoContent)
+	a.expectStatus(a.do("POST", "/api/refresh", user.RefreshToken, nil), http.StatusUnauthorized)
+}
+
+func TestSessions(t *testing.T) {
+	a := newTestAPI(t)
+	phone := a.signup("sessions@example.com")
+	laptop := a.login("sessions@example.com", "password")
+
+	a.expectStatus(a.do("GET", "/api/sessions", "", nil), http.StatusUnauthorized)
+	w := a.do("GET", "/api/sessions", laptop.Token, nil)
+	a.expectStatus(w, http.StatusOK)
+	sessions := decodeBody[[]struct {
+		ID uuid.UUID `json:"id"`
+	}](t, w)
+	if len(sessions) != 2 {
+		t.Fatalf("expected 2 sessions, received %d", len(sessions))
+	}
+
+	other := a.signup("other@example.com")
+	a.expectStatus(a.do("DELETE", "/api/sessions/"+sessions[0].ID.String(), other.Token, nil), http.StatusNotFound)
+	a.expectStatus(a.do("DELETE", "/api/sessions/not-a-uuid", laptop.Token, nil), http.StatusBadRequest)
+	a.expectStatus(a.do("DELETE", "/api/sessions/"+sessions[0].ID.String(), laptop.Token, nil), http.StatusNoContent)
+	w = a.do("GET", "/api/sessions", laptop.Token, nil)
+	a.expectStatus(w, http.StatusOK)
+	if remaining := decodeBody[[]struct{}](t, w); len(remaining) != 1 {
+		t.Fatalf("expected 1 session, received %d", len(remaining))
+	}
+
+	a.expectStatus(a.do("DELETE", "/api/sessions", phone.Token, nil), http.StatusNoContent)
+	a.expectStatus(a.do("POST", "/api/refresh", phone.RefreshToken, nil), http.StatusUnauthorized)
+	a.expectStatus(a.do("POST", "/api/refresh", laptop.RefreshToken, nil), http.StatusUnauthorized)
+}
+
+func TestSessionMetadata(t *testing.T) {
+	a := newTestAPI(t)
+	a.signup("devices@example.com")
+	send := func(path, token, userAgent string, body any) *httptest.ResponseRecorder {
+		t.Helper()
+		var buf bytes.Buffer
+		if body != nil {
+			if err := json.NewEncoder(&buf).Encode(body); err != nil {
+				t.Fatalf("failed to encode body: %v", err)
+			}
+		}
+		r := httptest.NewRequest("POST", path, &buf)
+		r.Header.Set("User-Agent", userAgent)
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		a.handler.ServeHTTP(w, r)
+		a.expectStatus(w, http.StatusOK)
+		return w
+	}
+	credentials := map[string]string{"email": "devices@example.com", "password": "password"}
+	phone := decodeBody[testUser](t, send("/api/login", "", "phone", credentials))
+	laptop := decodeBody[testUser](t, send("/api/login", "", "laptop", credentials))
+	send("/api/refresh", phone.RefreshToken, "phone, updated", nil)
+
+	type session struct {
+		CreatedAt  time.Time `json:"created_at"`
+		LastUsedAt time.Time `json:"last_used_at"`
+		UserAgent  string    `json:"user_agent"`
+		IPAddress  string    `json:"ip_address"`
+	}
+	w := a.do("GET", "/api/sessions", laptop.Token, nil)
+	a.expectStatus(w, http.StatusOK)
+	sessions := decodeBody[[]session](t, w)
+	if len(sessions) != 3 {
+		t.Fatalf("expected the signup, phone and laptop sessions, received %+v", sessions)
+	}
+	// The most recently used session comes first, with the details of its
+	// last use.
+	if sessions[0].UserAgent != "phone, updated" || !sessions[0].LastUsedAt.After(sessions[0].CreatedAt) {
+		t.Errorf("expected the refreshed phone session first, received %+v", sessions[0])
+	}
+	if sessions[1].UserAgent != "laptop" {
+		t.Errorf("expected the laptop session second, received %+v", sessions[1])
+	}
+	for _, s := range sessions {
+		if s.IPAddress != "192.0.2.1" {
+			t.Errorf("expected the client address, received %+v", s)
+		}
+	}
+}
+
+func TestChirps(t *testing.T) {
+	a := newTestAPI(t)
+	author := a.signup("author@example.com")
+	other := a.signup("other@example.com")
+
+	a.expectStatus(a.do("POST", "/api/chirps", "", map[string]string{"body": "hello"}), http.StatusUnauthorized)
+	a.expectStatus(a.do("POST", "/api/chirps", author.Token, map[string]string{"body": strings.Repeat("a", 141)}), http.StatusBadRequest)
+	a.expectStatus(a.do("POST", "/api/chirps", author.Token, map[string]string{"body": " "}), http.StatusBadRequest)
+
+	chirp := a.chirp(author, "what a kerfuffle")
+	if chirp.Body != "what a ****" || chirp.UserID != author.ID {
+		t.Errorf("unexpected chirp: %+v", chirp)
+	}
+
+	w := a.do("GET", "/api/chirps/"+chirp.ID.String(), "", nil)
+	a.expectStatus(w, http.StatusOK)
+	if received := decodeBody[testChirp](t, w); received != chirp {
+		t.Errorf("expected %+v, received %+v", chirp, received)
+	}
+	a.expectStatus(a.do("GET", "/api/chirps/not-a-uuid", "", nil), http.StatusBadRequest)
+	a.expectStatus(a.do("GET", "/api/chirps/"+uuid.NewString(), "", nil), http.StatusNotFound)
+
+	a.expectStatus(a.do("DELETE", "/api/chirps/"+chirp.ID.String(), "", nil), http.StatusUnauthorized)
+	a.expectStatus(a.do("DELETE", "/api/chirps/"+chirp.ID.String(), other.Token, nil), http.StatusForbidden)
+	a.expectStatus(a.do("DELETE", "/api/chirps/"+chirp.ID.String(), author.Token, nil), http.StatusNoContent)
+	a.expectStatus(a.do("GET", "/api/chirps/"+chirp.ID.String(), "", nil), http.StatusNotFound)
+}
+
+func TestEditChirp(t *testing.T) {
+	a := newTestAPI(t)
+	author := a.signup("author@example.com")
+	other := a.signup("other@example.com")
+	chirp := a.chirp(author, "first draft")
+	path := "/api/chirps/" + chirp.ID.String()
+
+	a.expectStatus(a.do("PUT", path, "", map[string]string{"body": "edit"}), http.StatusUnauthorized)
+	a.expectStatus(a.do("PUT", path, other.Token, map[string]string{"body": "edit"}), http.StatusForbidden)
+	a.expectStatus(a.do("PUT", path, author.Token, map[string]string{"body": strings.Repeat("a", 141)}), http.StatusBadRequest)
+	a.expectStatus(a.do("PUT", "/api/chirps/"+uuid.NewString(), author.Token, map[string]string{"body": "edit"}), http.StatusNotFound)
+
+	for _, body := range []string{"second draft", "final kerfuffle", "final kerfuffle"} {
+		w := a.do("PUT", path, author.Token, map[string]string{"body": body})
+		a.expectStatus(w, http.StatusOK)
+		if edited := decodeBody[testChirp](t, w); edited.ID != chirp.ID || edited.Body != a.cfg.moderation.Check(body).Text {
+			t.Errorf("unexpected chirp after editing to %q: %+v", body, edited)
+		}
+	}
+
+	type revision struct {
+		Body string `json:"body"`
+	}
+	revisions := walk[revision](a, path+"/revisions?limit=1", "")
+	if len(revisions) != 2 || revisions[0].Body != "second draft" || revisions[1].Body != "first draft" {
+		t.Errorf("expected the two previous bodies newest first, received %+v", revisions)
+	}
+	a.expectStatus(a.do("GET", "/api/chirps/"+uuid.NewString()+"/revisions", "", nil), http.StatusNotFound)
+
+	a.cfg.chirpEditWindow = 0
+	a.expectStatus(a.do("PUT", path, author.Token, map[string]string{"body": "too late"}), http.StatusForbidden)
+}
+
+func TestRepliesAndThreads(t *testing.T) {
+	a := newTestAPI(t)
+	author := a.signup("author@example.com")
+	other := a.signup("other@example.com")
+
+	type threadChirp struct {
+		ID         uuid.UUID  `json:"id"`
+		Body       string     `json:"body"`
+		InReplyTo  *uuid.UUID `json:"in_reply_to"`
+		ReplyCount int64      `json:"reply_count"`
+		Deleted    bool       `json:"deleted"`
+	}
+	type thread struct {
+		Ancestors []threadChirp `json:"ancestors"`
+		Chirp     threadChirp   `json:"chirp"`
+		Replies   []threadChirp `json:"replies"`
+	}
+	reply := func(user testUser, parent uuid.UUID, body string) threadChirp {
+		w := a.do("POST", "/api/chirps", user.Token, map[string]any{"body": body, "in_reply_to": parent})
+		a.expectStatus(w, http.StatusCreated)
+		return decodeBody[threadChirp](t, w)
+	}
+	getThread := func(path string) (thread, string) {
+		w := a.do("GET", path, "", nil)
+		a.expectStatus(w, http.StatusOK)
+		link := w.Header().Get("Link")
+		return decodeBody[thread](t, w), strings.TrimPrefix(strings.TrimSuffix(link, `>; rel="next"`), "<")
+	}
+	ids := func(chirps []threadChirp) []uuid.UUID {
+		var ids []uuid.UUID
+		for _, c := range chirps {
+			ids = append(ids, c.ID)
+		}
+		return ids
+	}
+
+	root := a.chirp(author, "root")
+	first := reply(other, root.ID, "first reply")
+	nested := reply(author, first.ID, "nested reply")
+	second := reply(other, root.ID, "second reply")
+	if first.InReplyTo == nil || *first.InReplyTo != root.ID {
+		t.Errorf("expected a reply to %v, received %+v", root.ID, first)
+	}
+	a.expectStatus(a.do("POST", "/api/chirps", other.Token, map[string]any{"body": "orphan", "in_reply_to": uuid.New()}), http.StatusBadRequest)
+
+	w := a.do("GET", "/api/chirps/"+root.ID.String(), "", nil)
+	a.expectStatus(w, http.StatusOK)
+	if c := decodeBody[threadChirp](t, w); c.ReplyCount != 2 || c.InReplyTo != nil {
+		t.Errorf("expected a root chirp with 2 replies, received %+v", c)
+	}
+
+	th, _ := getThread("/api/chirps/" + nested.ID.String() + "/thread")
+	if fmt.Sprint(ids(th.Ancestors)) != fmt.Sprint([]uuid.UUID{root.ID, first.ID}) || th.Chirp.ID != nested.ID || len(th.Replies) != 0 {
+		t.Errorf("unexpected thread of the nested reply: %+v", th)
+	}
+	th, next := getThread("/api/chirps/" + root.ID.String() + "/thread?limit=2")
+	if len(th.Ancestors) != 0 || fmt.Sprint(ids(th.Replies)) != fmt.Sprint([]uuid.UUID{first.ID, nested.ID}) || next == "" {
+		t.Fatalf("unexpected first page of the thread: %+v (next %q)", th, next)
+	}
+	th, next = getThread(next)
+	if fmt.Sprint(ids(th.Replies)) != fmt.Sprint([]uuid.UUID{second.ID}) || next != "" {
+		t.Errorf("unexpected second page of the thread: %+v (next %q)", th, next)
+	}
+	a.expectStatus(a.do("GET", "/api/chirps/"+uuid.NewString()+"/thread", "", nil), http.StatusNotFound)
+
+	// A deleted chirp with replies stays in its thread as a tombstone.
+	a.expectStatus(a.do("DELETE", "/api/chirps/"+first.ID.String(), other.Token, nil), http.StatusNoContent)
+	a.expectStatus(a.do("GET", "/api/chirps/"+first.ID.String(), "", nil), http.StatusNotFound)
+	a.expectStatus(a.do("POST", "/api/chirps", author.Token, map[string]any{"body": "late", "in_reply_to": first.ID}), http.StatusBadRequest)
+	th, _ = getThread("/api/chirps/" + nested.ID.String() + "/thread")
+	if len(th.Ancestors) != 2 || !th.Ancestors[1].Deleted || th.Ancestors[1].Body != "" {
+		t.Errorf("expected the deleted parent as a tombstone, received %+v", th.Ancestors)
+	}
+	a.expectStatus(a.do("DELETE", "/api/chirps/"+second.ID.String(), other.Token, nil), http.StatusNoContent)
+	th, _ = getThread("/api/chirps/" + root.ID.String() + "/thread")
+	if fmt.Sprint(ids(th.Replies)) != fmt.Sprint([]uuid.UUID{first.ID, nested.ID}) || th.Chirp.ReplyCount != 0 {
+		t.Errorf("unexpected thread after deleting replies: %+v", th)
+	}
+	for _, c := range walk[threadChirp](a, "/api/chirps", "") {
+		if c.ID == first.ID || c.ID == second.ID {
+			t.Errorf("deleted chirp %v is still listed", c.ID)
+		}
+	}
+
+	// The root only has a tombstone as a reply now, it is kept as well so the
+	// nested reply stays in the thread.
+	a.expectStatus(a.do("DELETE", "/api/chirps/"+root.ID.String(), author.Token, nil), http.StatusNoContent)
+	th, _ = getThread("/api/chirps/" + nested.ID.String() + "/thread")
+	if fmt.Sprint(ids(th.Ancestors)) != fmt.Sprint([]uuid.UUID{root.ID, first.ID}) || !th.Ancestors[0].Deleted {
+		t.Errorf("expected the deleted root as a tombstone, received %+v", th.Ancestors)
+	}
+}
+
+func TestLikes(t *testing.T) {
+	a := newTestAPI(t)
+	author := a.signup("author@example.com")
+	fan := a.signup("fan@example.com")
+
+	type likedChirp struct {
+		ID        uuid.UUID `json:"id"`
+		LikeCount int64     `json:"like_count"`
+		LikedByMe *bool     `json:"liked_by_me"`
+	}
+	type like struct {
+		UserID uuid.UUID `json:"user_id"`
+	}
+	getChirp := func(id uuid.UUID, token string) likedChirp {
+		w := a.do("GET", "/api/chirps/"+id.String(), token, nil)
+		a.expectStatus(w, http.StatusOK)
+		return decodeBody[likedChirp](t, w)
+	}
+
+	first := a.chirp(author, "first")
+	second := a.chirp(author, "second")
+	for range 2 {
+		a.expectStatus(a.do("POST", "/api/chirps/"+first.ID.String()+"/like", fan.Token, nil), http.StatusNoContent)
+	}
+	a.expectStatus(a.do("POST", "/api/chirps/"+second.ID.String()+"/like", fan.Token, nil), http.StatusNoContent)
+	a.expectStatus(a.do("POST", "/api/chirps/"+uuid.NewString()+"/like", fan.Token, nil), http.StatusNotFound)
+	a.expectStatus(a.do("POST", "/api/chirps/"+first.ID.String()+"/like", "", nil), http.StatusUnauthorized)
+
+	if c := getChirp(first.ID, ""); c.LikeCount != 1 || c.LikedByMe != nil {
+		t.Errorf("expected 1 like and no liked_by_me for an anonymous caller, received %+v", c)
+	}
+	if c := getChirp(first.ID, fan.Token); c.LikedByMe == nil || !*c.LikedByMe {
+		t.Errorf("expected liked_by_me for the fan, received %+v", c)
+	}
+	if c := getChirp(first.ID, author.Token); c.LikedByMe == nil || *c.LikedByMe {
+		t.Errorf("expected liked_by_me false for the author, received %+v", c)
+	}
+	a.expectStatus(a.do("GET", "/api/chirps/"+first.ID.String(), "not-a-token", nil), http.StatusUnauthorized)
+
+	likes := walk[like](a, "/api/chirps/"+first.ID.String()+"/likes", "")
+	if len(likes) != 1 || likes[0].UserID != fan.ID {
+		t.Errorf("expected the fan to like the chirp, received %+v", likes)
+	}
+	liked := walk[likedChirp](a, "/api/users/"+fan.ID.String()+"/likes?limit=1", fan.Token)
+	if len(liked) != 2 || liked[0].ID != second.ID || liked[1].ID != first.ID {
+		t.Errorf("expected the liked chirps, most recent first, received %+v", liked)
+	}
+
+	for range 2 {
+		a.expectStatus(a.do("DELETE", "/api/chirps/"+first.ID.String()+"/like", fan.Token, nil), http.StatusNoContent)
+	}
+	if c := getChirp(first.ID, fan.Token); c.LikeCount != 0 || *c.LikedByMe {
+		t.Errorf("expected the like to be removed, received %+v", c)
+	}
+
+	// Every user liking at once counts once each, however often they retry.
+	var users []testUser
+	for i := range 5 {
+		users = append(users, a.signup(fmt.Sprintf("user%d@example.com", i)))
+	}
+	var wg sync.WaitGroup
+	for _, u := range users {
+		for range 3 {
+			wg.Go(func() {
+				if w := a.do("POST", "/api/chirps/"+second.ID.String()+"/like", u.Token, nil); w.Code != http.StatusNoContent {
+					t.Errorf("expected status %d, received %d: %s", http.StatusNoContent, w.Code, w.Body.String())
+				}
+			})
+		}
+	}
+	wg.Wait()
+	if c := getChirp(second.ID, ""); c.LikeCount != int64(len(users))+1 {
+		t.Errorf("expected %d likes, received %d", len(users)+1, c.LikeCount)
+	}
+}
+
+// deletingStore deletes every chirp right after it is read, as a concurrent
+// request would.
+type deletingStore struct {
+	*memstore.Store
+}
+
+func (s deletingStore) GetChirpByID(ctx context.Context, id uuid.UUID) (database.Chirp, error) {
+	chirp, err := s.Store.GetChirpByID(ctx, id)
+	if err == nil {
+		err = s.Store.DeleteChirpByID(ctx, id)
+	}
+	return chirp, err
+}
+
+func TestLikeDeletedChirp(t *testing.T) {
+	a := newTestAPI(t)
+	author := a.signup("author@example.com")
+	fan := a.signup("fan@example.com")
+	c := a.chirp(author, "soon gone")
+	a.cfg.db = deletingStore{a.cfg.db.(*memstore.Store)}
+	a.expectStatus(a.do("POST", "/api/chirps/"+c.ID.String()+"/like", fan.Token, nil), http.StatusNotFound)
+}
+
+func TestRechirpsAndQuotes(t *testing.T) {
+	a := newTestAPI(t)
+	author := a.signup("author@example.com")
+	fan := a.signup("fan@example.com")
+
+	type embedded struct {
+		ID      uuid.UUID `json:"id"`
+		Body    string    `json:"body"`
+		Deleted bool      `json:"deleted"`
+	}
+	type repost struct {
+		ID           uuid.UUID `json:"id"`
+		Body         string    `json:"body"`
+		UserID       uuid.UUID `json:"user_id"`
+		RechirpCount int64     `json:"rechirp_count"`
+		QuoteCount   int64     `json:"quote_count"`
+		RechirpOf    *embedded `json:"rechirp_of"`
+		QuoteOf      *embedded `json:"quote_of"`
+	}
+	post := func(path string, user testUser, body any, expected int) repost {
+		w := a.do("POST", path, user.Token, body)
+		a.expectStatus(w, expected)
+		return decodeBody[repost](t, w)
+	}
+	get := func(id uuid.UUID) repost {
+		w := a.do("GET", "/api/chirps/"+id.String(), "", nil)
+		a.expectStatus(w, http.StatusOK)
+		return decodeBody[repost](t, w)
+	}
+
+	original := a.chirp(author, "original")
+	rechirp := post("/api/chirps/"+original.ID.String()+"/rechirp", fan, nil, http.StatusCreated)
+	if rechirp.Body != "" || rechirp.UserID != fan.ID || rechirp.RechirpOf == nil || rechirp.RechirpOf.ID != original.ID {
+		t.Errorf("expected a rechirp embedding the original, received %+v", rechirp)
+	}
+	if again := post("/api/chirps/"+rechirp.ID.String()+"/rechirp", fan, nil, http.StatusCreated); again.ID != rechirp.ID {
+		t.Errorf("expected rechirping again to return the first rechirp, received %+v", again)
+	}
+	quote := post("/api/chirps/"+rechirp.ID.String()+"/quote", author, map[string]string{"body": "quoting myself"}, http.StatusCreated)
+	if quote.Body != "quoting myself" || quote.QuoteOf == nil || quote.QuoteOf.ID != original.ID {
+		t.Errorf("expected a quote of the original, received %+v", quote)
+	}
+	post("/api/chirps/"+original.ID.String()+"/quote", author, map[string]string{"body": ""}, http.StatusBadRequest)
+	post("/api/chirps/"+uuid.NewString()+"/rechirp", fan, nil, http.StatusNotFound)
+	if c := get(original.ID); c.RechirpCount != 1 || c.QuoteCount != 1 {
+		t.Errorf("expected 1 rechirp and 1 quote, received %+v", c)
+	}
+
+	a.expectStatus(a.do("POST", "/api/chirps/"+rechirp.ID.String()+"/like", author.Token, nil), http.StatusBadRequest)
+	a.expectStatus(a.do("POST", "/api/chirps", author.Token, map[string]any{"body": "reply", "in_reply_to": rechirp.ID}), http.StatusBadRequest)
+	a.expectStatus(a.do("PUT", "/api/chirps/"+rechirp.ID.String(), fan.Token, map[string]string{"body": "edit"}), http.StatusBadRequest)
+
+	a.expectStatus(a.do("DELETE", "/api/chirps/"+original.ID.String()+"/rechirp", fan.Token, nil), http.StatusNoContent)
+	a.expectStatus(a.do("GET", "/api/chirps/"+rechirp.ID.String(), "", nil), http.StatusNotFound)
+	rechirp = post("/api/chirps/"+original.ID.String()+"/rechirp", fan, nil, http.StatusCreated)
+
+	// Deleting a quoted chirp leaves a tombstone in the quote and takes the
+	// plain rechirps with it.
+	a.expectStatus(a.do("DELETE", "/api/chirps/"+original.ID.String(), author.Token, nil), http.StatusNoContent)
+	a.expectStatus(a.do("GET", "/api/chirps/"+rechirp.ID.String(), "", nil), http.StatusNotFound)
+	if c := get(quote.ID); c.QuoteOf == nil || !c.QuoteOf.Deleted || c.QuoteOf.Body != "" {
+		t.Errorf("expected the quote to embed a tombstone, received %+v", c)
+	}
+	post("/api/chirps/"+original.ID.String()+"/rechirp", fan, nil, http.StatusNotFound)
+
+	other := a.chirp(author, "other")
+	rechirp = post("/api/chirps/"+other.ID.String()+"/rechirp", fan, nil, http.StatusCreated)
+	a.expectStatus(a.do("DELETE", "/api/chirps/"+other.ID.String(), author.Token, nil), http.StatusNoContent)
+	for _, c := range walk[repost](a, "/api/chirps", "") {
+		if c.ID == other.ID || c.ID == rechirp.ID {
+			t.Errorf("expected %v to be deleted with its rechirps", c.ID)
+		}
+	}
+}
+
+func TestHashtags(t *testing.T) {
+	a := newTestAPI(t)
+	user := a.signup("user@example.com")
+
+	type trend struct {
+		Tag        string `json:"tag"`
+		ChirpCount int64  `json:"chirp_count"`
+	}
+	ids := func(chirps []testChirp) []uuid.UUID {
+		var ids []uuid.UUID
+		for _, c := range chirps {
+			ids = append(ids, c.ID)
+		}
+		return ids
+	}
+	trending := func() []trend {
+		w := a.do("GET", "/api/hashtags/trending", "", nil)
+		a.expectStatus(w, http.StatusOK)
+		return decodeBody[[]trend](t, w)
+	}
+
+	first := a.chirp(user, "Learning #Go, #café-style.")
+	second := a.chirp(user, "More #go and #chirpy!")
+	a.chirp(user, "C# is not a tag, neither is #2024")
+
+	tagged := walk[testChirp](a, "/api/hashtags/go/chirps?limit=1", "")
+	if fmt.Sprint(ids(tagged)) != fmt.Sprint([]uuid.UUID{second.ID, first.ID}) {
+		t.Errorf("expected both #go chirps, newest first, received %+v", tagged)
+	}
+	if tagged := walk[testChirp](a, "/api/hashtags/%23Caf%C3%A9/chirps", ""); fmt.Sprint(ids(tagged)) != fmt.Sprint([]uuid.UUID{first.ID}) {
+		t.Errorf("expected the #café chirp, received %+v", tagged)
+	}
+	a.expectStatus(a.do("GET", "/api/hashtags/not%20a%20tag/chirps", "", nil), http.StatusBadRequest)
+	if tr := trending(); fmt.Sprint(tr) != fmt.Sprint([]trend{{"go", 2}, {"café", 1}, {"chirpy", 1}}) {
+		t.Errorf("unexpected trending hashtags: %+v", tr)
+	}
+
+	// Edits and deletes update the index.
+	a.expectStatus(a.do("PUT", "/api/chirps/"+second.ID.String(), user.Token, map[string]string{"body": "Only #chirpy now"}), http.StatusOK)
+	a.expectStatus(a.do("DELETE", "/api/chirps/"+first.ID.String(), user.Token, nil), http.StatusNoContent)
+	if tagged := walk[testChirp](a, "/api/hashtags/go/chirps", ""); len(tagged) != 0 {
+		t.Errorf("expected no #go chirps left, received %+v", tagged)
+	}
+	if tr := trending(); fmt.Sprint(tr) != fmt.Sprint([]trend{{"chirpy", 1}}) {
+		t.Errorf("unexpected trending hashtags after the edit: %+v", tr)
+	}
+
+	a.cfg.trendingWindow = time.Nanosecond
+	if tr := trending(); len(tr) != 0 {
+		t.Errorf("expected nothing trending in the last nanosecond, received %+v", tr)
+	}
+}
+
+func TestUsernamesAndMentions(t *testing.T) {
+	a := newTestAPI(t)
+	type user struct {
+		ID       uuid.UUID `json:"id"`
+		Username *string   `json:"username"`
+	}
+	create := func(email string, username any, expected int) user {
+		w := a.do("POST", "/api/users", "", map[string]any{
+			"email":    email,
+			"password": "password",
+			"username": username,
+		})
+		a.expectStatus(w, expected)
+		return decodeBody[user](t, w)
+	}
+
+	if bob := create("bob@example.com", "Bob", http.StatusCreated); bob.Username == nil || *bob.Username != "Bob" {
+		t.Errorf("expected the username Bob, received %+v", bob)
+	}
+	if anon := create("anon@example.com", nil, http.StatusCreated); anon.Username != nil {
+		t.Errorf("expected no username, received %+v", anon)
+	}
+	create("other@example.com", "bOB", http.StatusConflict)
+	create("other@example.com", "admin", http.StatusBadRequest)
+	create("other@example.com", "no spaces", http.StatusBadRequest)
+
+	bob := a.login("bob@example.com", "password")
+	alice := a.signup("alice@example.com")
+	w := a.do("PUT", "/api/users", alice.Token, map[string]any{"email": "alice@example.com", "password": "password", "username": "alice"})
+	a.expectStatus(w, http.StatusOK)
+	w = a.do("PUT", "/api/users", alice.Token, map[string]any{"email": "alice@example.com", "password": "password"})
+	a.expectStatus(w, http.StatusOK)
+	if u := decodeBody[user](t, w); u.Username == nil || *u.Username != "alice" {
+		t.Errorf("expected the username to be kept, received %+v", u)
+	}
+	a.expectStatus(a.do("PUT", "/api/users", alice.Token, map[string]any{"email": "alice@example.com", "password": "password", "username": "BOB"}), http.StatusConflict)
+
+	mention := a.chirp(alice, "hey @BOB, meet @nobody")
+	self := a.chirp(bob, "talking to myself @bob")
+	a.chirp(alice, "mail bob@example.com")
+	edited := a.chirp(alice, "@bob will not see this")
+	a.expectStatus(a.do("PUT", "/api/chirps/"+edited.ID.String(), alice.Token, map[string]string{"body": "never mind"}), http.StatusOK)
+
+	mentions := walk[testChirp](a, "/api/users/me/mentions?limit=1", bob.Token)
+	if len(mentions) != 2 || mentions[0].ID != self.ID || mentions[1].ID != mention.ID {
+		t.Errorf("expected the two chirps mentioning bob, received %+v", mentions)
+	}
+	if mentions := walk[testChirp](a, "/api/users/me/mentions", alice.Token); len(mentions) != 0 {
+		t.Errorf("expected no mentions of alice, received %+v", mentions)
+	}
+	a.expectStatus(a.do("GET", "/api/users/me/mentions", "", nil), http.StatusUnauthorized)
+}
+
+func TestSearch(t *testing.T) {
+	a := newTestAPI(t)
+	type result struct {
+		ID        uuid.UUID `json:"id"`
+		Highlight string    `json:"highlight"`
+	}
+	search := func(q string) []result {
+		return walk[result](a, "/api/search?limit=1&q="+url.QueryEscape(q), "")
+	}
+	ids := func(results []result) map[uuid.UUID]bool {
+		ids := map[uuid.UUID]bool{}
+		for _, r := range results {
+			ids[r.ID] = true
+		}
+		return ids
+	}
+
+	user := a.signup("user@example.com")
+	often := a.chirp(user, "Go is fun, go go go")
+	learning := a.chirp(user, "learning go today")
+	rust := a.chirp(user, "rust is fun")
+	compiler := a.chirp(user, "the go compiler is fast")
+	markup := a.chirp(user, "<b>rust</b> & more")
+
+	results := search("go")
+	if len(results) != 3 || results[0].ID != often.ID || !ids(results)[learning.ID] || !ids(results)[compiler.ID] {
+		t.Errorf("expected the three go chirps, the one saying it most first, received %+v", results)
+	}
+	cases := []struct {
+		query    string
+		expected []uuid.UUID
+	}{
+		{query: `"is fun"`, expected: []uuid.UUID{often.ID, rust.ID}},
+		{query: `"fun is"`},
+		{query: "go -today", expected: []uuid.UUID{often.ID, compiler.ID}},
+		{query: "compiler or today", expected: []uuid.UUID{learning.ID, compiler.ID}},
+	}
+	for _, c := range cases {
+		results := ids(search(c.query))
+		if len(results) != len(c.expected) {
+			t.Errorf("query %q: expected %d chirps, received %v", c.query, len(c.expected), results)
+		}
+		for _, id := range c.expected {
+			if !results[id] {
+				t.Errorf("query %q: expected chirp %s, received %v", c.query, id, results)
+			}
+		}
+	}
+
+	a.expectStatus(a.do("DELETE", "/api/chirps/"+rust.ID.String(), user.Token, nil), http.StatusNoContent)
+	results = search("rust")
+	if len(results) != 1 || results[0].ID != markup.ID {
+		t.Fatalf("expected only the chirp left about rust, received %+v", results)
+	}
+	if expected := "&lt;b&gt;<mark>rust</mark>&lt;/b&gt; &amp; more"; results[0].Highlight != expected {
+		t.Errorf("expected the highlight %q, received %q", expected, results[0].Highlight)
+	}
+
+	for _, username := range []string{"alicia", "alice", "al_x", "bob"} {
+		u := a.signup(username + "@example.com")
+		a.expectStatus(a.do("PUT", "/api/users", u.Token, map[string]string{
+			"email":    username + "@example.com",
+			"password": "password",
+			"username": username,
+		}), http.StatusOK)
+	}
+	type match struct {
+		Username string `json:"username"`
+	}
+	if users := walk[match](a, "/api/search?type=users&limit=1&q=%40ALI", ""); fmt.Sprint(users) != "[{alice} {alicia}]" {
+		t.Errorf("expected alice and alicia, received %+v", users)
+	}
+	if users := walk[match](a, "/api/search?type=users&q=al_", ""); fmt.Sprint(users) != "[{al_x}]" {
+		t.Errorf("expected _ to match itself only, received %+v", users)
+	}
+
+	a.expectStatus(a.do("GET", "/api/search", "", nil), http.StatusBadRequest)
+	a.expectStatus(a.do("GET", "/api/search?q=go&type=hashtags", "", nil), http.StatusBadRequest)
+	a.expectStatus(a.do("GET", "/api/search?q=go&cursor=not-a-cursor", "", nil), http.StatusBadRequest)
+}
+
+func TestModeration(t *testing.T) {
+	a := newTestAPI(t)
+	user := a.signup("user@example.com")
+	admin := a.signupAs("admin@example.com", auth.RoleAdmin)
+	type rule struct {
+		Word   string `json:"word"`
+		Action string `json:"action"`
+	}
+	put := func(word, action string) *httptest.ResponseRecorder {
+		return a.do("PUT", "/admin/moderation/rules/"+word, admin.Token, map[string]string{"action": action})
+	}
+
+	if chirp := a.chirp(user, "what a K3rfüffle!"); chirp.Body != "what a ****!" {
+		t.Errorf("expected the word masked, received %q", chirp.Body)
+	}
+
+	a.expectStatus(put("Spam", "flag"), http.StatusOK)
+	a.expectStatus(put("slur", "reject"), http.StatusOK)
+	a.expectStatus(put("slur", "delete"), http.StatusBadRequest)
+	a.expectStatus(put("!!", "mask"), http.StatusBadRequest)
+	w := a.do("GET", "/admin/moderation/rules", admin.Token, nil)
+	a.expectStatus(w, http.StatusOK)
+	if rules := decodeBody[[]rule](t, w); fmt.Sprint(rules) != "[{fornax mask} {kerfuffle mask} {sharbert mask} {slur reject} {spam flag}]" {
+		t.Errorf("unexpected rules: %+v", rules)
+	}
+
+	a.expectStatus(a.do("POST", "/api/chirps", user.Token, map[string]string{"body": "you $lur"}), http.StatusBadRequest)
+	spam := a.chirp(user, "buy sp4m")
+	a.chirp(user, "no words to flag")
+	type flagged struct {
+		Chirp testChirp `json:"chirp"`
+		Words []string  `json:"words"`
+	}
+	flags := walk[flagged](a, "/admin/moderation/flags?limit=1", admin.Token)
+	if len(flags) != 1 || flags[0].Chirp.ID != spam.ID || fmt.Sprint(flags[0].Words) != "[spam]" {
+		t.Errorf("expected the spam chirp flagged, received %+v", flags)
+	}
+	a.expectStatus(a.do("DELETE", "/api/chirps/"+spam.ID.String(), user.Token, nil), http.StatusNoContent)
+	if flags := walk[flagged](a, "/admin/moderation/flags", admin.Token); len(flags) != 0 {
+		t.Errorf("expected no flags left, received %+v", flags)
+	}
+
+	a.expectStatus(a.do("DELETE", "/admin/moderation/rules/SLUR", admin.Token, nil), http.StatusNoContent)
+	a.expectStatus(a.do("DELETE", "/admin/moderation/rules/slur", admin.Token, nil), http.StatusNotFound)
+	a.chirp(user, "you $lur")
+
+	mod := a.signupAs("mod@example.com", auth.RoleModerator)
+	a.expectStatus(a.do("GET", "/admin/moderation/flags", mod.Token, nil), http.StatusOK)
+	a.expectStatus(a.do("GET", "/admin/moderation/rules", mod.Token, nil), http.StatusForbidden)
+	a.expectStatus(a.do("PUT", "/admin/moderation/rules/spam", user.Token, map[string]string{"action": "mask"}), http.StatusForbidden)
+}
+
+func TestReports(t *testing.T) {
+	a := newTestAPI(t)
+	alice := a.signup("alice@example.com")
+	bob := a.signup("bob@example.com")
+	mod := a.signupAs("mod@example.com", auth.RoleModerator)
+	other := a.signupAs("other@example.com", auth.RoleModerator)
+	type report struct {
+		ID         uuid.UUID  `json:"id"`
+		UserID     uuid.UUID  `json:"user_id"`
+		ChirpID    *uuid.UUID `json:"chirp_id"`
+		ChirpBody  *string    `json:"chirp_body"`
+		Reason     string     `json:"reason"`
+		Status     string     `json:"status"`
+		ClaimedBy  *uuid.UUID `json:"claimed_by"`
+		ResolvedBy *uuid.UUID `json:"resolved_by"`
+		Resolution *string    `json:"resolution"`
+	}
+	reportChirp := func(chirp testChirp, reason string) report {
+		w := a.do("POST", "/api/chirps/"+chirp.ID.String()+"/report", alice.Token, map[string]string{"reason": reason})
+		a.expectStatus(w, http.StatusCreated)
+		return decodeBody[report](t, w)
+	}
+	resolve := func(user testUser, r report, resolution string) *httptest.ResponseRecorder {
+		return a.do("POST", "/admin/reports/"+r.ID.String()+"/resolve", user.Token, map[string]string{"resolution": resolution})
+	}
+
+	spam := a.chirp(bob, "buy now")
+	rude := a.chirp(bob, "you are all wrong")
+	spamReport := reportChirp(spam, "spam")
+	if spamReport.UserID != bob.ID || *spamReport.ChirpID != spam.ID || *spamReport.ChirpBody != "buy now" || spamReport.Status != "open" {
+		t.Errorf("unexpected report: %+v", spamReport)
+	}
+	rudeReport := reportChirp(rude, "harassment")
+	w := a.do("POST", "/api/users/"+bob.ID.String()+"/report", alice.Token, map[string]string{"reason": "impersonation", "details": "not the real bob"})
+	a.expectStatus(w, http.StatusCreated)
+	userReport := decodeBody[report](t, w)
+	if userReport.ChirpID != nil || userReport.ChirpBody != nil {
+		t.Errorf("expected a report about the user only, received %+v", userReport)
+	}
+
+	a.expectStatus(a.do("POST", "/api/chirps/"+spam.ID.String()+"/report", "", map[string]string{"reason": "spam"}), http.StatusUnauthorized)
+	a.expectStatus(a.do("POST", "/api/chirps/"+spam.ID.String()+"/report", alice.Token, map[string]string{"reason": "boring"}), http.StatusBadRequest)
+	a.expectStatus(a.do("POST", "/api/chirps/"+spam.ID.String()+"/report", bob.Token, map[string]string{"reason": "spam"}), http.StatusBadRequest)
+	a.expectStatus(a.do("POST", "/api/chirps/"+uuid.NewString()+"/report", alice.Token, map[string]string{"reason": "spam"}), http.StatusNotFound)
+	a.expectStatus(a.do("POST", "/api/users/"+uuid.NewString()+"/report", alice.Token, map[string]string{"reason": "spam"}), http.StatusNotFound)
+
+	if open := walk[report](a, "/admin/reports?limit=2", mod.Token); len(open) != 3 || open[0].ID != spamReport.ID {
+		t.Errorf("expected the three reports, oldest first, received %+v", open)
+	}
+	a.expectStatus(a.do("GET", "/admin/reports?status=closed", mod.Token, nil), http.StatusBadRequest)
+
+	w = a.do("POST", "/admin/reports/"+rudeReport.ID.String()+"/claim", mod.Token, nil)
+	a.expectStatus(w, http.StatusOK)
+	if claimed := decodeBody[report](t, w); claimed.Status != "claimed" || *claimed.ClaimedBy != mod.ID {
+		t.Errorf("expected the report claimed by the moderator, received %+v", claimed)
+	}
+	a.expectStatus(a.do("POST", "/admin/reports/"+rudeReport.ID.String()+"/claim", mod.Token, nil), http.StatusOK)
+	a.expectStatus(a.do("POST", "/admin/reports/"+rudeReport.ID.String()+"/claim", other.Token, nil), http.StatusConflict)
+	a.expectStatus(resolve(other, rudeReport, "dismiss"), http.StatusConflict)
+	a.expectStatus(a.do("POST", "/admin/reports/"+uuid.NewString()+"/claim", mod.Token, nil), http.StatusNotFound)
+	if claimed := walk[report](a, "/admin/reports?status=claimed", mod.Token); len(claimed) != 1 || claimed[0].ID != rudeReport.ID {
+		t.Errorf("expected the claimed report, received %+v", claimed)
+	}
+
+	w = resolve(mod, rudeReport, "dismiss")
+	a.expectStatus(w, http.StatusOK)
+	if resolved := decodeBody[report](t, w); resolved.Status != "resolved" || *resolved.ResolvedBy != mod.ID || *resolved.Resolution != "dismiss" {
+		t.Errorf("expected the report dismissed by the moderator, received %+v", resolved)
+	}
+	a.expectStatus(resolve(mod, rudeReport, "hide_chirp"), http.StatusConflict)
+	a.expectStatus(a.do("GET", "/api/chirps/"+rude.ID.String(), "", nil), http.StatusOK)
+
+	a.expectStatus(resolve(other, userReport, "hide_chirp"), http.StatusBadRequest)
+	a.expectStatus(resolve(other, spamReport, "ban"), http.StatusBadRequest)
+	a.expectStatus(resolve(other, spamReport, "hide_chirp"), http.StatusOK)
+	a.expectStatus(a.do("GET", "/api/chirps/"+spam.ID.String(), "", nil), http.StatusNotFound)
+
+	a.expectStatus(resolve(other, userReport, "suspend_user"), http.StatusOK)
+	a.expectStatus(a.do("POST", "/api/refresh", bob.RefreshToken, nil), http.StatusUnauthorized)
+	a.expectStatus(a.do("POST", "/api/login", "", map[string]string{
+		"email":    bob.Email,
+		"password": "password",
+	}), http.StatusForbidden)
+
+	resolved := walk[report](a, "/admin/reports?status=resolved", mod.Token)
+	if len(resolved) != 3 || *resolved[0].ResolvedBy != other.ID || *resolved[0].ChirpBody != "buy now" {
+		t.Errorf("expected the three reports resolved, with the hidden chirp kept, received %+v", resolved)
+	}
+	if open := walk[report](a, "/admin/reports", mod.Token); len(open) != 0 {
+		t.Errorf("expected no open report, received %+v", open)
+	}
+
+	a.expectStatus(a.do("GET", "/admin/reports", alice.Token, nil), http.StatusForbidden)
+}
+
+func TestRoles(t *testing.T) {
+	a := newTestAPI(t)
+	admin := a.signupAs("admin@example.com", auth.RoleAdmin)
+	bob := a.signup("bob@example.com")
+	setRole := func(caller testUser, userID uuid.UUID, role string) *httptest.ResponseRecorder {
+		return a.do("PUT", "/admin/users/"+userID.String()+"/role", caller.Token, map[string]string{"role": role})
+	}
+
+	a.expectStatus(a.do("GET", "/admin/metrics", "", nil), http.StatusUnauthorized)
+	a.expectStatus(a.do("GET", "/admin/metrics", bob.Token, nil), http.StatusForbidden)
+	a.expectStatus(a.do("GET", "/admin/reports", bob.Token, nil), http.StatusForbidden)
+
+	// Roles are checked in the database, tokens issued before a change
+	// follow it.
+	a.expectStatus(setRole(admin, bob.ID, "moderator"), http.StatusOK)
+	a.expectStatus(a.do("GET", "/admin/reports", bob.Token, nil), http.StatusOK)
+	a.expectStatus(a.do("GET", "/admin/metrics", bob.Token, nil), http.StatusForbidden)
+	a.expectStatus(setRole(bob, admin.ID, "user"), http.StatusForbidden)
+
+	w := a.do("POST", "/api/login", "", map[string]string{"email": bob.Email, "password": "password"})
+	a.expectStatus(w, http.StatusOK)
+	moderator := decodeBody[struct {
+		Token string `json:"token"`
+		Role  string `json:"role"`
+	}](t, w)
+	if moderator.Role != "moderator" {
+		t.Errorf("expected the moderator role, received %q", moderator.Role)
+	}
+	a.expectStatus(setRole(admin, bob.ID, "user"), http.StatusOK)
+	a.expectStatus(a.do("GET", "/admin/reports", moderator.Token, nil), http.StatusForbidden)
+
+	a.expectStatus(setRole(admin, admin.ID, "user"), http.StatusBadRequest)
+	a.expectStatus(setRole(admin, bob.ID, "root"), http.StatusBadRequest)
+	a.expectStatus(setRole(admin, uuid.New(), "admin"), http.StatusNotFound)
+}
+
+func TestSuspensions(t *testing.T) {
+	a := newTestAPI(t)
+	admin := a.signupAs("admin@example.com", auth.RoleAdmin)
+	mod := a.signupAs("mod@example.com", auth.RoleModerator)
+	bob := a.signup("bob@example.com")
+	carol := a.signup("carol@example.com")
+	fromBob := a.chirp(bob, "#hello from bob")
+	a.chirp(carol, "#hello from carol")
+	a.expectStatus(a.do("POST", "/api/users/"+bob.ID.String()+"/follow", carol.Token, nil), http.StatusNoContent)
+	a.expectStatus(a.do("POST", "/api/chirps/"+fromBob.ID.String()+"/like", carol.Token, nil), http.StatusNoContent)
+	type suspension struct {
+		Status       string     `json:"status"`
+		Until        *time.Time `json:"until"`
+		Reason       string     `json:"reason"`
+		ChirpsHidden bool       `json:"chirps_hidden"`
+	}
+	suspend := func(caller testUser, user testUser, params map[string]any) *httptest.ResponseRecorder {
+		return a.do("PUT", "/admin/users/"+user.ID.String()+"/suspension", caller.Token, params)
+	}
+	lift := func(caller testUser, user testUser) *httptest.ResponseRecorder {
+		return a.do("DELETE", "/admin/users/"+user.ID.String()+"/suspension", caller.Token, nil)
+	}
+	// authors lists who has chirps in any of the listings, the timeline and
+	// the likes of carol included.
+	authors := func() map[uuid.UUID]bool {
+		authors := map[uuid.UUID]bool{}
+		for _, path := range []string{
+			"/api/chirps",
+			"/api/search?q=hello",
+			"/api/hashtags/hello/chirps",
+			"/api/timeline",
+			"/api/users/" + carol.ID.String() + "/likes",
+		} {
+			for _, c := range walk[testChirp](a, path, carol.Token) {
+				authors[c.UserID] = true
+			}
+		}
+		return authors
+	}
+
+	until := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	w := suspend(mod, bob, map[string]any{"reason": "spam", "until": until, "hide_chirps": true})
+	a.expectStatus(w, http.StatusOK)
+	if s := decodeBody[suspension](t, w); s.Status != "suspended" || !s.Until.Equal(until) || s.Reason != "spam" || !s.ChirpsHidden {
+		t.Errorf("unexpected suspension: %+v", s)
+	}
+	w = a.do("POST", "/api/chirps", bob.Token, map[string]string{"body": "still here"})
+	a.expectStatus(w, http.StatusForbidden)
+	if msg := w.Body.String(); !strings.Contains(msg, "suspended until") || !strings.Contains(msg, "spam") {
+		t.Errorf("expected the end and the reason of the suspension, received %s", msg)
+	}
+	a.expectStatus(a.do("GET", "/api/timeline", bob.Token, nil), http.StatusOK)
+	a.expectStatus(a.do("POST", "/api/refresh", bob.RefreshToken, nil), http.StatusUnauthorized)
+	a.expectStatus(a.do("POST", "/api/login", "", map[string]string{"email": bob.Email, "password": "password"}), http.StatusForbidden)
+	if authors := authors(); authors[bob.ID] || !authors[carol.ID] {
+		t.Errorf("expected the chirps of bob hidden, received the authors %v", authors)
+	}
+
+	a.expectStatus(suspend(mod, bob, map[string]any{"reason": "spam", "banned": true}), http.StatusForbidden)
+	a.expectStatus(suspend(mod, admin, map[string]any{"reason": "spam"}), http.StatusForbidden)
+	a.expectStatus(suspend(mod, mod, map[string]any{"reason": "spam"}), http.StatusBadRequest)
+	a.expectStatus(suspend(mod, carol, map[string]any{}), http.StatusBadRequest)
+	a.expectStatus(suspend(mod, carol, map[string]any{"reason": "spam", "until": time.Now().Add(-time.Hour)}), http.StatusBadRequest)
+	a.expectStatus(suspend(bob, carol, map[string]any{"reason": "spam"}), http.StatusForbidden)
+
+	w = suspend(admin, bob, map[string]any{"reason": "repeated spam", "banned": true})
+	a.expectStatus(w, http.StatusOK)
+	if s := decodeBody[suspension](t, w); s.Status != "banned" || s.Until != nil || !s.ChirpsHidden {
+		t.Errorf("unexpected ban: %+v", s)
+	}
+	a.expectStatus(lift(mod, bob), http.StatusForbidden)
+	// Nor can a moderator turn the ban into a shorter suspension.
+	a.expectStatus(suspend(mod, bob, map[string]any{"reason": "spam", "until": time.Now().Add(time.Minute)}), http.StatusForbidden)
+	a.expectStatus(suspend(mod, bob, map[string]any{"reason": "spam", "banned": false}), http.StatusForbidden)
+	a.expectStatus(a.do("POST", "/api/login", "", map[string]string{"email": bob.Email, "password": "password"}), http.StatusForbidden)
+	a.expectStatus(lift(admin, bob), http.StatusOK)
+	bob = a.login(bob.Email, "password")
+	a.chirp(bob, "back again")
+	if authors := authors(); !authors[bob.ID] {
+		t.Errorf("expected the chirps of bob back, received the authors %v", authors)
+	}
+
+	a.expectStatus(suspend(mod, carol, map[string]any{"reason": "rude"}), http.StatusOK)
+	if authors := authors(); !authors[carol.ID] {
+		t.Errorf("expected the chirps of carol shown, received the authors %v", authors)
+	}
+	_, err := a.cfg.db.SuspendUser(context.Background(), database.SuspendUserParams{
+		SuspendedAt:      time.Now().Add(-2 * time.Hour).UTC(),
+		SuspendedUntil:   sql.NullTime{Time: time.Now().Add(-time.Hour).UTC(), Valid: true},
+		SuspensionReason: "rude",
+		ChirpsHidden:     true,
+		ID:               carol.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.login(carol.Email, "password")
+	if authors := authors(); !authors[carol.ID] {
+		t.Errorf("expected the suspension of carol over, received the authors %v", authors)
+	}
+}
+
+func TestGetChirps(t *testing.T) {
+	a := newTestAPI(t)
+	alice := a.signup("alice@example.com")
+	bob := a.signup("bob@example.com")
+	var created []testChirp
+	for i := range 5 {
+		user := alice
+		if i%2 == 1 {
+			user = bob
+		}
+		created = append(created, a.chirp(user, fmt.Sprintf("chirp %d", i)))
+	}
+
+	all := walk[testChirp](a, "/api/chirps?limit=2", "")
+	if len(all) != len(created) {
+		t.Fatalf("expected %d chirps, received %d", len(created), len(all))
+	}
+	for i := range created {
+		if all[i].ID != created[i].ID {
+			t.Errorf("chirp %d: expected %v, received %v", i, created[i].ID, all[i].ID)
+		}
+	}
+
+	desc := walk[testChirp](a, "/api/chirps?limit=2&sort=desc", "")
+	for i := range created {
+		if desc[i].ID != created[len(created)-1-i].ID {
+			t.Errorf("chirp %d: expected %v, received %v", i, created[len(created)-1-i].ID, desc[i].ID)
+		}
+	}
+
+	byBob := walk[testChirp](a, "/api/chirps?limit=1&author_id="+bob.ID.String(), "")
+	if len(byBob) != 2 || byBob[0].UserID != bob.ID || byBob[1].UserID != bob.ID {
+		t.Errorf("expected 2 chirps by bob, received %+v", byBob)
+	}
+
+	a.expectStatus(a.do("GET", "/api/chirps?sort=sideways", "", nil), http.StatusBadRequest)
+	a.expectStatus(a.do("GET", "/api/chirps?author_id=nope", "", nil), http.StatusBadRequest)
+	a.expectStatus(a.do("GET", "/api/chirps?limit=0", "", nil), http.StatusBadRequest)
+	a.expectStatus(a.do("GET", "/api/chirps?cursor=nope", "", nil), http.StatusBadRequest)
+}
+
+func TestGetChirpsByAuthorSorted(t *testing.T) {
+	a := newTestAPI(t)
+	alice := a.signup("alice@example.com")
+	bob := a.signup("bob@example.com")
+	var byBob []testChirp
+	for i := range 6 {
+		if i%2 == 0 {
+			a.chirp(alice, fmt.Sprintf("alice %d", i))
+			continue
+		}
+		byBob = append(byBob, a.chirp(bob, fmt.Sprintf("bob %d", i)))
+	}
+
+	asc := walk[testChirp](a, "/api/chirps?limit=2&sort=asc&author_id="+bob.ID.String(), "")
+	desc := walk[testChirp](a, "/api/chirps?limit=2&sort=desc&author_id="+bob.ID.String(), "")
+	if len(asc) != len(byBob) || len(desc) != len(byBob) {
+		t.Fatalf("expected %d chirps by bob each way, received %d and %d", len(byBob), len(asc), len(desc))
+	}
+	for i := range byBob {
+		if asc[i].ID != byBob[i].ID {
+			t.Errorf("asc chirp %d: expected %v, received %v", i, byBob[i].ID, asc[i].ID)
+		}
+		if desc[i].ID != byBob[len(byBob)-1-i].ID {
+			t.Errorf("desc chirp %d: expected %v, received %v", i, byBob[len(byBob)-1-i].ID, desc[i].ID)
+		}
+	}
+	if chirps := walk[testChirp](a, "/api/chirps?author_id="+uuid.NewString(), ""); len(chirps) != 0 {
+		t.Errorf("expected no chirps for an unknown author, received %+v", chirps)
+	}
+
+	for _, query := range []string{"sort=ASC", "author_id=" + bob.ID.String()[:8]} {
+		w := a.do("GET", "/api/chirps?"+query, "", nil)
+		a.expectStatus(w, http.StatusBadRequest)
+		if body := decodeBody[map[string]string](t, w); body["error"] == "" {
+			t.Errorf("%s: expected a JSON error, received %v", query, body)
+		}
+	}
+}
+
+func TestFollowsAndTimeline(t *testing.T) {
+	a := newTestAPI(t)
+	alice := a.signup("alice@example.com")
+	bob := a.signup("bob@example.com")
+	carol := a.signup("carol@example.com")
+	a.chirp(bob, "from bob")
+	a.chirp(carol, "from carol")
+	a.chirp(bob, "bob again")
+
+	follow := "/api/users/" + bob.ID.String() + "/follow"
+	a.expectStatus(a.do("POST", follow, "", nil), http.StatusUnauthorized)
+	a.expectStatus(a.do("POST", "/api/users/"+alice.ID.String()+"/follow", alice.Token, nil), http.StatusBadRequest)
+	a.expectStatus(a.do("POST", "/api/users/"+uuid.NewString()+"/follow", alice.Token, nil), http.StatusNotFound)
+	a.expectStatus(a.do("POST", follow, alice.Token, nil), http.StatusNoContent)
+	a.expectStatus(a.do("POST", follow, alice.Token, nil), http.StatusNoContent)
+
+	a.expectStatus(a.do("GET", "/api/timeline", "", nil), http.StatusUnauthorized)
+	timeline := walk[testChirp](a, "/api/timeline?limit=1", alice.Token)
+	if len(timeline) != 2 || timeline[0].Body != "bob again" || timeline[1].Body != "from bob" {
+		t.Errorf("unexpected timeline: %+v", timeline)
+	}
+
+	type followEntry struct {
+		UserID uuid.UUID `json:"user_id"`
+	}
+	followers := walk[followEntry](a, "/api/users/"+bob.ID.String()+"/followers", "")
+	if len(followers) != 1 || followers[0].UserID != alice.ID {
+		t.Errorf("unexpected followers: %+v", followers)
+	}
+	following := walk[followEntry](a, "/api/users/"+alice.ID.String()+"/following", "")
+	if len(following) != 1 || following[0].UserID != bob.ID {
+		t.Errorf("unexpected following: %+v", following)
+	}
+
+	a.expectStatus(a.do("DELETE", follow, alice.Token, nil), http.StatusNoContent)
+	if timeline := walk[testChirp](a, "/api/timeline", alice.Token); len(timeline) != 0 {
+		t.Errorf("expected an empty timeline, received %+v", timeline)
+	}
+}
+
+func TestFollowListsAndTimelinePages(t *testing.T) {
+	a := newTestAPI(t)
+	alice := a.signup("alice@example.com")
+	bob := a.signup("bob@example.com")
+	carol := a.signup("carol@example.com")
+	dave := a.signup("dave@example.com")
+	for _, u := range []testUser{alice, bob, carol} {
+		a.expectStatus(a.do("POST", "/api/users/"+dave.ID.String()+"/follow", u.Token, nil), http.StatusNoContent)
+	}
+	for _, u := range []testUser{bob, carol} {
+		a.expectStatus(a.do("POST", "/api/users/"+u.ID.String()+"/follow", alice.Token, nil), http.StatusNoContent)
+	}
+
+	type followEntry struct {
+		UserID uuid.UUID `json:"user_id"`
+	}
+	followers := walk[followEntry](a, "/api/users/"+dave.ID.String()+"/followers?limit=1", "")
+	if len(followers) != 3 || followers[0].UserID != carol.ID || followers[1].UserID != bob.ID || followers[2].UserID != alice.ID {
+		t.Errorf("expected dave's followers newest first, received %+v", followers)
+	}
+	following := walk[followEntry](a, "/api/users/"+alice.ID.String()+"/following?limit=2", "")
+	if len(following) != 3 || following[0].UserID != carol.ID || following[1].UserID != bob.ID || following[2].UserID != dave.ID {
+		t.Errorf("expected alice's follows newest first, received %+v", following)
+	}
+
+	var expected []testChirp
+	for i := range 5 {
+		author := []testUser{bob, carol, dave, alice}[i%4]
+		c := a.chirp(author, fmt.Sprintf("chirp %d", i))
+		if author.ID != alice.ID {
+			expected = append([]testChirp{c}, expected...)
+		}
+	}
+	timeline := walk[testChirp](a, "/api/timeline?limit=2", alice.Token)
+	if len(timeline) != len(expected) {
+		t.Fatalf("expected %d chirps in the timeline, received %+v", len(expected), timeline)
+	}
+	for i := range expected {
+		if timeline[i].ID != expected[i].ID {
+			t.Errorf("timeline chirp %d: expected %v, received %v", i, expected[i].ID, timeline[i].ID)
+		}
+	}
+
+	a.expectStatus(a.do("DELETE", "/api/users/"+alice.ID.String()+"/follow", bob.Token, nil), http.StatusNoContent)
+	for _, path := range []string{"/api/users/nope/followers", "/api/users/nope/following"} {
+		a.expectStatus(a.do("GET", path, "", nil), http.StatusBadRequest)
+	}
+	a.expectStatus(a.do("POST", "/api/users/nope/follow", alice.Token, nil), http.StatusBadRequest)
+	a.expectStatus(a.do("DELETE", "/api/users/nope/follow", alice.Token, nil), http.StatusBadRequest)
+	a.expectStatus(a.do("GET", "/api/timeline?cursor=nope", alice.Token, nil), http.StatusBadRequest)
+}
+
+func TestBlocksAndMutes(t *testing.T) {
+	a := newTestAPI(t)
+	alice := a.signup("alice@example.com")
+	bob := a.signup("bob@example.com")
+	carol := a.signup("carol@example.com")
+	a.expectStatus(a.do("PUT", "/api/users", alice.Token, map[string]any{"email": "alice@example.com", "password": "password", "username": "alice"}), http.StatusOK)
+	a.expectStatus(a.do("POST", "/api/users/"+alice.ID.String()+"/follow", bob.Token, nil), http.StatusNoContent)
+	a.expectStatus(a.do("POST", "/api/users/"+bob.ID.String()+"/follow", alice.Token, nil), http.StatusNoContent)
+
+	block := "/api/users/" + bob.ID.String() + "/block"
+	a.expectStatus(a.do("POST", block, "", nil), http.StatusUnauthorized)
+	a.expectStatus(a.do("POST", "/api/users/"+alice.ID.String()+"/block", alice.Token, nil), http.StatusBadRequest)
+	a.expectStatus(a.do("POST", "/api/users/"+uuid.NewString()+"/block", alice.Token, nil), http.StatusNotFound)
+	a.expectStatus(a.do("POST", block, alice.Token, nil), http.StatusNoContent)
+	a.expectStatus(a.do("POST", block, alice.Token, nil), http.StatusNoContent)
+
+	type followEntry struct {
+		UserID uuid.UUID `json:"user_id"`
+	}
+	if followers := walk[followEntry](a, "/api/users/"+alice.ID.String()+"/followers", ""); len(followers) != 0 {
+		t.Errorf("expected the block to remove bob's follow, received %+v", followers)
+	}
+	if following := walk[followEntry](a, "/api/users/"+alice.ID.String()+"/following", ""); len(following) != 0 {
+		t.Errorf("expected the block to remove alice's follow, received %+v", following)
+	}
+	a.expectStatus(a.do("POST", "/api/users/"+alice.ID.String()+"/follow", bob.Token, nil), http.StatusForbidden)
+
+	root := a.chirp(alice, "hello")
+	a.expectStatus(a.do("POST", "/api/chirps", bob.Token, map[string]any{"body": "hi", "in_reply_to": root.ID}), http.StatusForbidden)
+	a.expectStatus(a.do("POST", "/api/chirps", carol.Token, map[string]any{"body": "hi", "in_reply_to": root.ID}), http.StatusCreated)
+
+	a.chirp(bob, "hey @alice")
+	fromCarol := a.chirp(carol, "hey @alice")
+	mentions := walk[testChirp](a, "/api/users/me/mentions", alice.Token)
+	if len(mentions) != 1 || mentions[0].ID != fromCarol.ID {
+		t.Errorf("expected only carol's mention, received %+v", mentions)
+	}
+
+	a.expectStatus(a.do("DELETE", block, alice.Token, nil), http.StatusNoContent)
+	a.expectStatus(a.do("POST", "/api/users/"+alice.ID.String()+"/follow", bob.Token, nil), http.StatusNoContent)
+	a.expectStatus(a.do("POST", "/api/chirps", bob.Token, map[string]any{"body": "hi", "in_reply_to": root.ID}), http.StatusCreated)
+
+	a.expectStatus(a.do("PUT", "/api/users", carol.Token, map[string]any{"email": "carol@example.com", "password": "password", "username": "carol"}), http.StatusOK)
+	fromBob := a.chirp(bob, "#news from bob to @carol")
+	fromAlice := a.chirp(alice, "#news from alice to @carol")
+	for _, c := range []testChirp{fromBob, fromAlice} {
+		a.expectStatus(a.do("POST", "/api/chirps/"+c.ID.String()+"/like", alice.Token, nil), http.StatusNoContent)
+	}
+	mute := "/api/users/" + bob.ID.String() + "/mute"
+	a.expectStatus(a.do("POST", mute, "", nil), http.StatusUnauthorized)
+	a.expectStatus(a.do("POST", "/api/users/"+carol.ID.String()+"/mute", carol.Token, nil), http.StatusBadRequest)
+	a.expectStatus(a.do("POST", "/api/users/"+uuid.NewString()+"/mute", carol.Token, nil), http.StatusNotFound)
+	a.expectStatus(a.do("POST", mute, carol.Token, nil), http.StatusNoContent)
+	a.expectStatus(a.do("POST", "/api/users/"+bob.ID.String()+"/follow", carol.Token, nil), http.StatusNoContent)
+	a.expectStatus(a.do("POST", "/api/users/"+alice.ID.String()+"/follow", carol.Token, nil), http.StatusNoContent)
+	a.expectStatus(a.do("POST", "/api/users/"+carol.ID.String()+"/follow", bob.Token, nil), http.StatusNoContent)
+
+	countBob := func(chirps []testChirp) int {
+		n := 0
+		for _, c := range chirps {
+			if c.UserID == bob.ID {
+				n++
+			}
+		}
+		return n
+	}
+	for _, path := range []string{
+		"/api/chirps?limit=1",
+		"/api/chirps?limit=1&sort=desc",
+		"/api/timeline?limit=1",
+		"/api/search?limit=1&q=news",
+		"/api/hashtags/news/chirps?limit=1",
+		"/api/users/me/mentions?limit=1",
+		"/api/users/" + alice.ID.String() + "/likes?limit=1",
+	} {
+		if chirps := walk[testChirp](a, path, carol.Token); len(chirps) == 0 || countBob(chirps) != 0 {
+			t.Errorf("%s: expected chirps, none by bob, received %+v", path, chirps)
+		}
+	}
+	if chirps := walk[testChirp](a, "/api/chirps", ""); countBob(chirps) != 3 {
+		t.Errorf("expected bob's chirps when signed out, received %+v", chirps)
+	}
+	if chirps := walk[testChirp](a, "/api/chirps?author_id="+bob.ID.String(), carol.Token); countBob(chirps) != 3 {
+		t.Errorf("expected bob's chirps when asked for, received %+v", chirps)
+	}
+
+	a.expectStatus(a.do("DELETE", mute, carol.Token, nil), http.StatusNoContent)
+	for _, path := range []string{
+		"/api/timeline",
+		"/api/search?q=news",
+		"/api/hashtags/news/chirps",
+		"/api/users/me/mentions",
+		"/api/users/" + alice.ID.String() + "/likes",
+	} {
+		if chirps := walk[testChirp](a, path, carol.Token); countBob(chirps) == 0 {
+			t.Errorf("%s: expected bob's chirps back, received %+v", path, chirps)
+		}
+	}
+}
+
+func TestPolkaWebhooks(t *testing.T) {
+	a := newTestAPI(t)
+	user := a.signup("red@example.com")
+	upgrade := map[string]any{
+		"event": "user.upgraded",
+		"data":  map[string]string{"user_id": user.ID.String()},
+	}
+	send := func(apiKey string, body any) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		_ = json.NewEncoder(&buf).Encode(body)
+		r := httptest.NewRequest("POST", "/api/polka/webhooks", &buf)
+		if apiKey != "" {
+			r.Header.Set("Authorization", "ApiKey "+apiKey)
+		}
+		w := httptest.NewRecorder()
+		a.handler.ServeHTTP(w, r)
+		return w
+	}
+
+	a.expectStatus(send("", upgrade), http.StatusUnauthorized)
+	a.expectStatus(send("wrong key", upgrade), http.StatusUnauthorized)
+	a.expectStatus(send(a.cfg.polkaKey, map[string]any{"event": "user.payment_failed"}), http.StatusNoContent)
+	a.expectStatus(send(a.cfg.polkaKey, map[string]any{
+		"event": "user.upgraded",
+		"data":  map[string]string{"user_id": uuid.NewString()},
+	}), http.StatusNotFound)
+	a.expectStatus(send(a.cfg.polkaKey, upgrade), http.StatusNoContent)
+
+	if user := a.login(user.Email, "password"); !user.IsChirpyRed {
+		t.Errorf("expected user to be upgraded")
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/Specialized101/chirpy/internal/database"
+	"github.com/Specialized101/chirpy/internal/handle"
+	"github.com/Specialized101/chirpy/internal/hashtag"
+	"github.com/google/uuid"
+)
+
+// chirpJSON is a chirp as the API returns it. Deleted chirps only show up in
+// threads and quotes, as tombstones without a body that keep the replies in
+// place. LikedByMe is only set when the request is authenticated.
+//
+// A rechirp has no body of its own and embeds the chirp it reposts in
+// RechirpOf, a quote embeds the quoted chirp in QuoteOf. Embedded chirps do
+// not embed further.
+type chirpJSON struct {
+	ID           uuid.UUID  `json:"id"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	Body         string     `json:"body"`
+	UserID       uuid.UUID  `json:"user_id"`
+	InReplyTo    *uuid.UUID `json:"in_reply_to"`
+	ReplyCount   int64      `json:"reply_count"`
+	LikeCount    int64      `json:"like_count"`
+	LikedByMe    *bool      `json:"liked_by_me,omitempty"`
+	RechirpCount int64      `json:"rechirp_count"`
+	QuoteCount   int64      `json:"quote_count"`
+	RechirpOf    *chirpJSON `json:"rechirp_of,omitempty"`
+	QuoteOf      *chirpJSON `json:"quote_of,omitempty"`
+	Deleted      bool       `json:"deleted,omitempty"`
+}
+
+// chirpsJSON converts chirps for a response, with the chirps they rechirp or
+// quote.
+func (cfg *apiConfig) chirpsJSON(ctx context.Context, chirps []database.Chirp) ([]chirpJSON, error) {
+	data, err := cfg.chirpDetails(ctx, chirps)
+	if err != nil {
+		return nil, err
+	}
+	var originalIDs []uuid.UUID
+	for _, c := range chirps {
+		if c.DeletedAt.Valid {
+			continue
+		}
+		if c.RechirpOfID.Valid {
+			originalIDs = append(originalIDs, c.RechirpOfID.UUID)
+		}
+		if c.QuoteOfID.Valid {
+			originalIDs = append(originalIDs, c.QuoteOfID.UUID)
+		}
+	}
+	if len(originalIDs) == 0 {
+		return data, nil
+	}
+	originals, err := cfg.db.GetChirpsByIDs(ctx, originalIDs)
+	if err != nil {
+		return nil, err
+	}
+	originalData, err := cfg.chirpDetails(ctx, originals)
+	if err != nil {
+		return nil, err
+	}
+	byID := map[uuid.UUID]*chirpJSON{}
+	for i := range originalData {
+		byID[originalData[i].ID] = &originalData[i]
+	}
+	for i, c := range chirps {
+		if c.DeletedAt.Valid {
+			continue
+		}
+		if c.RechirpOfID.Valid {
+			data[i].RechirpOf = byID[c.RechirpOfID.UUID]
+		}
+		if c.QuoteOfID.Valid {
+			data[i].QuoteOf = byID[c.QuoteOfID.UUID]
+		}
+	}
+	return data, nil
+}
+
+// chirpDetails converts chirps without what they embed. What is shown about
+// the chirps besides their columns is loaded with one query per kind for the
+// whole list.
+func (cfg *apiConfig) chirpDetails(ctx context.Context, chirps []database.Chirp) ([]chirpJSON, error) {
+	data := []chirpJSON{}
+	if len(chirps) == 0 {
+		return data, nil
+	}
+	ids := make([]uuid.UUID, 0, len(chirps))
+	for _, c := range chirps {
+		ids = append(ids, c.ID)
+	}
+	counts, err := cfg.db.GetReplyCounts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	replyCounts := map[uuid.UUID]int64{}
+	for _, c := range counts {
+		replyCounts[c.ChirpID] = c.ReplyCount
+	}
+	likes, err := cfg.db.GetLikeCounts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	likeCounts := map[uuid.UUID]int64{}
+	for _, l := range likes {
+		likeCounts[l.ChirpID] = l.LikeCount
+	}
+	reposts, err := cfg.db.GetRepostCounts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	repostCounts := map[uuid.UUID]database.GetRepostCountsRow{}
+	for _, r := range reposts {
+		repostCounts[r.ChirpID] = r
+	}
+	viewer, signedIn := userFromContext(ctx)
+	likedByViewer := map[uuid.UUID]bool{}
+	if signedIn {
+		liked, err := cfg.db.GetLikedChirpIDs(ctx, database.GetLikedChirpIDsParams{
+			UserID:   viewer.ID,
+			ChirpIds: ids,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range liked {
+			likedByViewer[id] = true
+		}
+	}
+
+	for _, c := range chirps {
+		item := chirpJSON{
+			ID:           c.ID,
+			CreatedAt:    c.CreatedAt,
+			UpdatedAt:    c.UpdatedAt,
+			Body:         c.Body,
+			UserID:       c.UserID,
+			ReplyCount:   replyCounts[c.ID],
+			LikeCount:    likeCounts[c.ID],
+			RechirpCount: repostCounts[c.ID].RechirpCount,
+			QuoteCount:   repostCounts[c.ID].QuoteCount,
+			Deleted:      c.DeletedAt.Valid,
+		}
+		if c.ParentID.Valid {
+			parentID := c.ParentID.UUID
+			item.InReplyTo = &parentID
+		}
+		if signedIn {
+			liked := likedByViewer[c.ID]
+			item.LikedByMe = &liked
+		}
+		data = append(data, item)
+	}
+	return data, nil
+}
+
+func (cfg *apiConfig) chirpJSON(ctx context.Context, chirp database.Chirp) (chirpJSON, error) {
+	data, err := cfg.chirpsJSON(ctx, []database.Chirp{chirp})
+	if err != nil {
+		return chirpJSON{}, err
+	}
+	return data[0], nil
+}
+
+// indexChirp stores the hashtags and the mentions of a chirp after it is
+// posted or edited, an edit drops the ones gone from the body. Users who
+// blocked the author are not mentioned. The chirp is saved by then, so a
+// failure is only logged: the chirp misses from a hashtag timeline or a
+// mentions list rather than being posted twice by a client retrying.
+func (cfg *apiConfig) indexChirp(ctx context.Context, chirp database.Chirp) {
+	// Empty rather than nil slices: a NULL array would match nothing, and
+	// remove nothing on an edit.
+	tags := hashtag.Extract(chirp.Body)
+	if tags == nil {
+		tags = []string{}
+	}
+	err := cfg.db.SetChirpHashtags(ctx, database.SetChirpHashtagsParams{
+		Tags:     tags,
+		TaggedAt: chirp.UpdatedAt,
+		ChirpID:  chirp.ID,
+	})
+	if err != nil {
+		log.Printf("failed to index the hashtags of chirp %s: %v", chirp.ID, err)
+	}
+	handles := handle.Extract(chirp.Body)
+	if handles == nil {
+		handles = []string{}
+	}
+	err = cfg.db.SetChirpMentions(ctx, database.SetChirpMentionsParams{
+		Handles:   handles,
+		AuthorID:  chirp.UserID,
+		ChirpID:   chirp.ID,
+		CreatedAt: chirp.UpdatedAt,
+	})
+	if err != nil {
+		log.Printf("failed to index the mentions of chirp %s: %v", chirp.ID, err)
+	}
+}
+
+// deleteChirp deletes a chirp, unless it has replies or quotes. Then it is
+// kept as a tombstone so the replies still show where they belong in the
+// thread and the quotes show that the quoted chirp was deleted. Plain
+// rechirps are deleted with the chirp either way.
+func deleteChirp(ctx context.Context, db database.Querier, chirpID uuid.UUID) error {
+	// Replies deleted as tombstones count, they may have replies of their own.
+	hasReplies, err := db.HasReplies(ctx, chirpID)
+	if err != nil {
+		return err
+	}
+	reposts, err := db.GetRepostCounts(ctx, []uuid.UUID{chirpID})
+	if err != nil {
+		return err
+	}
+	quoted := len(reposts) > 0 && reposts[0].QuoteCount > 0
+	if !hasReplies && !quoted {
+		return db.DeleteChirpByID(ctx, chirpID)
+	}
+	return db.TombstoneChirp(ctx, database.TombstoneChirpParams{
+		ID:        chirpID,
+		DeletedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+	})
+}
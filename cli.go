@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/Specialized101/chirpy/internal/config"
+	"github.com/Specialized101/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// cli is the state shared by the commands of the binary.
+type cli struct {
+	cfg     config.Config
+	db      *sql.DB
+	queries database.Querier
+	in      io.Reader
+	out     io.Writer
+	json    bool
+}
+
+// runFunc implements a command. args are the arguments left after its flags.
+type runFunc func(ctx context.Context, c *cli, args []string) error
+
+type command struct {
+	name    string
+	usage   string
+	summary string
+	// json reports whether the command supports -json.
+	json bool
+	// validate checks the configuration before connecting to the database.
+	// Most commands only need the database settings.
+	validate func(config.Config) error
+	// setup defines the flags of the command and returns its implementation.
+	setup func(fs *flag.FlagSet) runFunc
+}
+
+func noFlags(run runFunc) func(*flag.FlagSet) runFunc {
+	return func(*flag.FlagSet) runFunc {
+		return run
+	}
+}
+
+var commands = []command{
+	{
+		name:     "serve",
+		summary:  "run the HTTP server, the default when no command is given",
+		validate: config.Config.Validate,
+		setup:    noFlags(runServe),
+	},
+	{
+		name:    "migrate",
+		usage:   "up|down|status|redo",
+		summary: "apply, roll back or list the database migrations",
+		json:    true,
+		setup:   noFlags(runMigrate),
+	},
+	{
+		name:    "user create",
+		usage:   "[-username <name>] [-role <role>] <email>",
+		summary: "create a user, the password is read from standard input",
+		json:    true,
+		setup:   setupUserCreate,
+	},
+	{
+		name:    "user grant-red",
+		usage:   "<email|id|@username>",
+		summary: "upgrade a user to Chirpy Red",
+		json:    true,
+		setup:   noFlags(runUserGrantRed),
+	},
+	{
+		name:    "user set-role",
+		usage:   "<email|id|@username> user|moderator|admin",
+		summary: "change the role of a user, the way to make the first admin",
+		json:    true,
+		setup:   noFlags(runUserSetRole),
+	},
+	{
+		name:    "user reset-password",
+		usage:   "<email|id|@username>",
+		summary: "set a new password read from standard input and sign the user out everywhere",
+		json:    true,
+		setup:   noFlags(runUserResetPassword),
+	},
+	{
+		name:    "token revoke",
+		usage:   "<refresh-token> | -user <email|id|@username>",
+		summary: "revoke a refresh token, or every refresh token of a user",
+		json:    true,
+		setup:   setupTokenRevoke,
+	},
+	{
+		name:    "chirp delete",
+		usage:   "<chirp-id>",
+		summary: "delete a chirp",
+		json:    true,
+		setup:   noFlags(runChirpDelete),
+	},
+}
+
+// findCommand picks the command named by the first one or two arguments and
+// returns the arguments left for it. Without a command, or when the
+// arguments start with a flag, the server is started.
+func findCommand(args []string) (command, []string, error) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return commands[0], args, nil
+	}
+	for _, cmd := range commands {
+		words := strings.Fields(cmd.name)
+		if len(args) >= len(words) && slices.Equal(args[:len(words)], words) {
+			return cmd, args[len(words):], nil
+		}
+	}
+	return command{}, nil, fmt.Errorf("unknown command %q\n%s", strings.Join(args[:min(len(args), 2)], " "), commandsUsage())
+}
+
+func commandsUsage() string {
+	var b strings.Builder
+	b.WriteString("usage: chirpy <command> [flags] [arguments]\n\ncommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(&b, "  %-20s %s\n", cmd.name, cmd.summary)
+	}
+	return b.String()
+}
+
+// runCLI runs the command named by args (without the program name).
+func runCLI(ctx context.Context, args []string, lookupEnv func(string) (string, bool), in io.Reader, out io.Writer) error {
+	if len(args) > 0 && (args[0] == "help" || args[0] == "-h" || args[0] == "-help" || args[0] == "--help") {
+		fmt.Fprint(out, commandsUsage())
+		return nil
+	}
+	cmd, args, err := findCommand(args)
+	if err != nil {
+		return err
+	}
+
+	c := &cli{in: in, out: out}
+	run, args, err := cmd.parse(c, args, lookupEnv)
+	if err != nil {
+		return err
+	}
+	validate := cmd.validate
+	if validate == nil {
+		validate = config.Config.ValidateDatabase
+	}
+	if err := validate(c.cfg); err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	db, err := openDB(c.cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	c.db, c.queries = db, database.New(db)
+	return run(ctx, c, args)
+}
+
+// parse resolves the flags and configuration of cmd into c. It returns the
+// implementation of cmd and the arguments left after the flags.
+func (cmd command) parse(c *cli, args []string, lookupEnv func(string) (string, bool)) (runFunc, []string, error) {
+	fs := flag.NewFlagSet("chirpy "+cmd.name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: chirpy %s [flags] %s\n\n%s\n\nflags:\n", cmd.name, cmd.usage, cmd.summary)
+		fs.PrintDefaults()
+	}
+	if cmd.json {
+		fs.BoolVar(&c.json, "json", false, "print the result as JSON")
+	}
+	run := cmd.setup(fs)
+	cfg, err := config.Resolve(fs, args, lookupEnv)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.cfg = cfg
+	return run, fs.Args(), nil
+}
+
+// openDB connects to Postgres with the pool settings of c.
+func openDB(c config.DBConfig) (*sql.DB, error) {
+	db, err := sql.Open("postgres", c.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	db.SetMaxOpenConns(c.MaxOpenConns)
+	db.SetMaxIdleConns(c.MaxIdleConns)
+	db.SetConnMaxLifetime(c.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(c.ConnMaxIdleTime)
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("database not responding: %w", err)
+	}
+	return db, nil
+}
+
+// print writes v as JSON with -json, and the formatted text otherwise.
+func (c *cli) print(v any, format string, args ...any) error {
+	if c.json {
+		encoder := json.NewEncoder(c.out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	}
+	_, err := fmt.Fprintf(c.out, format+"\n", args...)
+	return err
+}
+
+// findUser looks a user up by id, by email or by @username.
+func (c *cli) findUser(ctx context.Context, s string) (database.User, error) {
+	var user database.User
+	var err error
+	if id, parseErr := uuid.Parse(s); parseErr == nil {
+		user, err = c.queries.GetUserByID(ctx, id)
+	} else if username, ok := strings.CutPrefix(s, "@"); ok {
+		user, err = c.queries.GetUserByUsername(ctx, username)
+	} else {
+		user, err = c.queries.GetUserByEmail(ctx, s)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.User{}, fmt.Errorf("user %q not found", s)
+	}
+	return user, err
+}
+
+// readPassword reads a password from the first line of standard input, so
+// it stays out of the shell history and process listings.
+func (c *cli) readPassword() (string, error) {
+	if f, ok := c.in.(*os.File); ok {
+		if info, err := f.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+			fmt.Fprint(os.Stderr, "Password: ")
+		}
+	}
+	scanner := bufio.NewScanner(c.in)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return "", fmt.Errorf("failed to read the password: %w", err)
+		}
+		return "", errors.New("password is required on standard input")
+	}
+	password := strings.TrimRight(scanner.Text(), "\r")
+	if strings.TrimSpace(password) == "" {
+		return "", errors.New("password is required on standard input")
+	}
+	return password, nil
+}
+
+func exactArgs(args []string, n int, usage string) error {
+	if len(args) != n {
+		return fmt.Errorf("usage: chirpy %s", usage)
+	}
+	return nil
+}
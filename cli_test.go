@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func envFrom(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+}
+
+// runCommand runs a command against the store of the test API, stdin is the
+// standard input of the command. It returns what the command printed.
+func (a *testAPI) runCommand(stdin string, args ...string) (string, error) {
+	a.t.Helper()
+	cmd, args, err := findCommand(args)
+	if err != nil {
+		return "", err
+	}
+	var out bytes.Buffer
+	c := &cli{queries: a.cfg.db, in: strings.NewReader(stdin), out: &out}
+	run, args, err := cmd.parse(c, args, envFrom(nil))
+	if err != nil {
+		return "", err
+	}
+	err = run(context.Background(), c, args)
+	return out.String(), err
+}
+
+func TestFindCommand(t *testing.T) {
+	cases := []struct {
+		args     []string
+		expected string
+		rest     []string
+	}{
+		{args: nil, expected: "serve"},
+		{args: []string{"-addr", ":8080"}, expected: "serve", rest: []string{"-addr", ":8080"}},
+		{args: []string{"migrate", "up"}, expected: "migrate", rest: []string{"up"}},
+		{args: []string{"user", "create", "a@example.com"}, expected: "user create", rest: []string{"a@example.com"}},
+		{args: []string{"user"}},
+		{args: []string{"user", "delete"}},
+		{args: []string{"launch"}},
+	}
+	for _, c := range cases {
+		cmd, rest, err := findCommand(c.args)
+		if c.expected == "" {
+			if err == nil {
+				t.Errorf("%q: expected an error, received command %q", c.args, cmd.name)
+			}
+			continue
+		}
+		if err != nil || cmd.name != c.expected || strings.Join(rest, " ") != strings.Join(c.rest, " ") {
+			t.Errorf("%q: expected %q %q, received %q %q (%v)", c.args, c.expected, c.rest, cmd.name, rest, err)
+		}
+	}
+}
+
+func TestRunCLIValidatesConfiguration(t *testing.T) {
+	cases := []struct {
+		args     []string
+		env      map[string]string
+		expected string
+	}{
+		{args: []string{"migrate", "up"}, expected: "DB_URL is required"},
+		{args: []string{"user", "grant-red", "-json", "a@example.com"}, expected: "DB_URL is required"},
+		{args: []string{"serve"}, env: map[string]string{"DB_URL": "postgres://"}, expected: "SECRET_KEY is required"},
+		{args: []string{"chirp", "delete", "-bogus"}, expected: "flag provided but not defined"},
+	}
+	for _, c := range cases {
+		err := runCLI(context.Background(), c.args, envFrom(c.env), strings.NewReader(""), io.Discard)
+		if err == nil || !strings.Contains(err.Error(), c.expected) {
+			t.Errorf("%q: expected an error containing %q, received %v", c.args, c.expected, err)
+		}
+	}
+}
+
+func TestParseServeConfiguration(t *testing.T) {
+	env := map[string]string{
+		"SECRET_KEY":       "0123456789abcdef0123456789abcdef",
+		"POLKA_KEY":        "polka",
+		"DB_URL":           "postgres://localhost/chirpy",
+		"CHIRP_MAX_LENGTH": "200",
+	}
+	cmd, args, err := findCommand([]string{"-chirp-max-length", "500", "-platform", "dev"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &cli{}
+	if _, _, err := cmd.parse(c, args, envFrom(env)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := cmd.validate(c.cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.cfg.Chirps.MaxLength != 500 || c.cfg.Platform != "dev" || c.cfg.DB.URL != env["DB_URL"] {
+		t.Errorf("expected the flags over the environment, received %+v", c.cfg)
+	}
+
+	cmd, args, _ = findCommand([]string{"serve", "-read-timeout", "0s"})
+	if _, _, err := cmd.parse(c, args, envFrom(env)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := cmd.validate(c.cfg); err == nil || !strings.Contains(err.Error(), "READ_TIMEOUT must be positive") {
+		t.Errorf("expected READ_TIMEOUT to be rejected, received %v", err)
+	}
+}
+
+func TestUserCommands(t *testing.T) {
+	a := newTestAPI(t)
+
+	out, err := a.runCommand("secret\n", "user", "create", "-json", "admin@example.com")
+	if err != nil {
+		t.Fatalf("user create: %v", err)
+	}
+	var created userJSON
+	if err := json.Unmarshal([]byte(out), &created); err != nil {
+		t.Fatalf("user create did not print JSON: %q", out)
+	}
+	if created.Email != "admin@example.com" {
+		t.Errorf("expected admin@example.com, received %+v", created)
+	}
+	user := a.login("admin@example.com", "secret")
+	if _, err := a.runCommand("secret\n", "user", "create", "admin@example.com"); err == nil {
+		t.Error("expected creating a duplicate user to fail")
+	}
+	if _, err := a.runCommand("", "user", "create", "other@example.com"); err == nil {
+		t.Error("expected a missing password to fail")
+	}
+	out, err = a.runCommand("secret\n", "user", "create", "-username", "Mod_1", "mod@example.com")
+	if err != nil {
+		t.Fatalf("user create -username: %v", err)
+	}
+	if _, err := a.runCommand("secret\n", "user", "create", "-username", "MOD_1", "mod2@example.com"); err == nil {
+		t.Error("expected a taken username to fail")
+	}
+	if out, err = a.runCommand("", "user", "grant-red", "@mod_1"); err != nil || !strings.Contains(out, "mod@example.com") {
+		t.Errorf("expected grant-red to find the user by username: %q %v", out, err)
+	}
+
+	out, err = a.runCommand("", "user", "grant-red", created.ID.String())
+	if err != nil || !strings.Contains(out, "Chirpy Red") {
+		t.Fatalf("user grant-red: %q %v", out, err)
+	}
+	if _, err := a.runCommand("", "user", "grant-red", "nobody@example.com"); err == nil {
+		t.Error("expected an unknown user to fail")
+	}
+
+	if out, err = a.runCommand("", "user", "set-role", "admin@example.com", "admin"); err != nil || !strings.Contains(out, "is now admin") {
+		t.Fatalf("user set-role: %q %v", out, err)
+	}
+	if _, err := a.runCommand("", "user", "set-role", "admin@example.com", "root"); err == nil {
+		t.Error("expected an unknown role to fail")
+	}
+	admin := a.login("admin@example.com", "secret")
+	a.expectStatus(a.do("GET", "/admin/metrics", admin.Token, nil), http.StatusOK)
+	out, err = a.runCommand("secret\n", "user", "create", "-role", "moderator", "-json", "mod3@example.com")
+	if err != nil || !strings.Contains(out, `"role": "moderator"`) {
+		t.Errorf("expected user create to set the role: %q %v", out, err)
+	}
+
+	if _, err := a.runCommand("new secret\n", "user", "reset-password", "admin@example.com"); err != nil {
+		t.Fatalf("user reset-password: %v", err)
+	}
+	a.expectStatus(a.do("POST", "/api/login", "", map[string]string{
+		"email":    "admin@example.com",
+		"password": "secret",
+	}), http.StatusUnauthorized)
+	a.login("admin@example.com", "new secret")
+	a.expectStatus(a.do("POST", "/api/refresh", user.RefreshToken, nil), http.StatusUnauthorized)
+}
+
+func TestTokenAndChirpCommands(t *testing.T) {
+	a := newTestAPI(t)
+	alice := a.signup("alice@example.com")
+	other := a.login("alice@example.com", "password")
+
+	if _, err := a.runCommand("", "token", "revoke", "unknown"); err == nil {
+		t.Error("expected an unknown refresh token to fail")
+	}
+	if _, err := a.runCommand("", "token", "revoke", alice.RefreshToken); err != nil {
+		t.Fatalf("token revoke: %v", err)
+	}
+	a.expectStatus(a.do("POST", "/api/refresh", alice.RefreshToken, nil), http.StatusUnauthorized)
+	a.expectStatus(a.do("POST", "/api/refresh", other.RefreshToken, nil), http.StatusOK)
+
+	if _, err := a.runCommand("", "token", "revoke", "-user", "alice@example.com"); err != nil {
+		t.Fatalf("token revoke -user: %v", err)
+	}
+	w := a.do("GET", "/api/sessions", alice.Token, nil)
+	a.expectStatus(w, http.StatusOK)
+	if sessions := decodeBody[[]map[string]any](t, w); len(sessions) != 0 {
+		t.Errorf("expected every session to be revoked, received %v", sessions)
+	}
+
+	chirp := a.chirp(alice, "delete me")
+	if _, err := a.runCommand("", "chirp", "delete", "not-a-uuid"); err == nil {
+		t.Error("expected an invalid chirp id to fail")
+	}
+	if _, err := a.runCommand("", "chirp", "delete", chirp.ID.String()); err != nil {
+		t.Fatalf("chirp delete: %v", err)
+	}
+	a.expectStatus(a.do("GET", "/api/chirps/"+chirp.ID.String(), "", nil), http.StatusNotFound)
+	if _, err := a.runCommand("", "chirp", "delete", chirp.ID.String()); err == nil {
+		t.Error("expected deleting a deleted chirp to fail")
+	}
+}
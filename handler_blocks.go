@@ -0,0 +1,124 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Specialized101/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// otherUserID reads the userID path value for the block and mute handlers,
+// which act on someone else who exists. It responds and returns false when
+// not, verb naming the action in the error.
+func (cfg *apiConfig) otherUserID(w http.ResponseWriter, r *http.Request, verb string) (uuid.UUID, bool) {
+	user, _ := userFromContext(r.Context())
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, "user id is not valid")
+		return uuid.Nil, false
+	}
+	if userID == user.ID {
+		_ = respondWithError(w, http.StatusBadRequest, fmt.Sprintf("cannot %s yourself", verb))
+		return uuid.Nil, false
+	}
+	if _, err := cfg.db.GetUserByID(r.Context(), userID); err != nil {
+		if err == sql.ErrNoRows {
+			_ = respondWithError(w, http.StatusNotFound, "the user does not exist")
+			return uuid.Nil, false
+		}
+		log.Printf("failed to get user by id: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// handlerBlockUser blocks a user: they can no longer follow the caller,
+// reply to their chirps or mention them. The follows between the two go,
+// both ways.
+func (cfg *apiConfig) handlerBlockUser(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	user, _ := userFromContext(r.Context())
+	blockedID, ok := cfg.otherUserID(w, r, "block")
+	if !ok {
+		return
+	}
+	err := cfg.db.CreateBlock(r.Context(), database.CreateBlockParams{
+		BlockerID: user.ID,
+		BlockedID: blockedID,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		log.Printf("failed to create block: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnblockUser(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	user, _ := userFromContext(r.Context())
+	blockedID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, "user id is not valid")
+		return
+	}
+	err = cfg.db.DeleteBlock(r.Context(), database.DeleteBlockParams{
+		BlockerID: user.ID,
+		BlockedID: blockedID,
+	})
+	if err != nil {
+		log.Printf("failed to delete block: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerMuteUser mutes a user: their chirps are left out of the listings the
+// caller gets, search and hashtags included, and of their timeline. The muted
+// user is not told about it.
+func (cfg *apiConfig) handlerMuteUser(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	user, _ := userFromContext(r.Context())
+	mutedID, ok := cfg.otherUserID(w, r, "mute")
+	if !ok {
+		return
+	}
+	err := cfg.db.CreateMute(r.Context(), database.CreateMuteParams{
+		MuterID:   user.ID,
+		MutedID:   mutedID,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		log.Printf("failed to create mute: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnmuteUser(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	user, _ := userFromContext(r.Context())
+	mutedID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, "user id is not valid")
+		return
+	}
+	err = cfg.db.DeleteMute(r.Context(), database.DeleteMuteParams{
+		MuterID: user.ID,
+		MutedID: mutedID,
+	})
+	if err != nil {
+		log.Printf("failed to delete mute: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
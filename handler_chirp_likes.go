@@ -0,0 +1,180 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Specialized101/chirpy/internal/database"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// chirpGone reports whether err comes from the foreign key of a like on a
+// chirp that does not exist.
+func chirpGone(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503" && pqErr.Constraint == "chirp_likes_chirp_id_fkey"
+}
+
+// handlerLikeChirp likes a chirp for the caller. Liking a chirp twice is not
+// an error, the like keeps its first date.
+func (cfg *apiConfig) handlerLikeChirp(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	user, _ := userFromContext(r.Context())
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, "Chirp id is not valid")
+		return
+	}
+	chirp, err := cfg.db.GetChirpByID(r.Context(), chirpID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			_ = respondWithError(w, http.StatusNotFound, "the chirp does not exist")
+			return
+		}
+		log.Printf("Failed to get chirp by id: %v\n", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	if chirp.RechirpOfID.Valid {
+		_ = respondWithError(w, http.StatusBadRequest, "cannot like a rechirp, like the original chirp instead")
+		return
+	}
+	err = cfg.db.CreateChirpLike(r.Context(), database.CreateChirpLikeParams{
+		UserID:    user.ID,
+		ChirpID:   chirpID,
+		CreatedAt: time.Now().UTC(),
+	})
+	// The chirp was deleted since it was read.
+	if chirpGone(err) {
+		_ = respondWithError(w, http.StatusNotFound, "the chirp does not exist")
+		return
+	}
+	if err != nil {
+		log.Printf("failed to create chirp like: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnlikeChirp(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	user, _ := userFromContext(r.Context())
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, "Chirp id is not valid")
+		return
+	}
+	err = cfg.db.DeleteChirpLike(r.Context(), database.DeleteChirpLikeParams{
+		UserID:  user.ID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		log.Printf("failed to delete chirp like: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerGetChirpLikes lists who liked a chirp, most recent like first.
+func (cfg *apiConfig) handlerGetChirpLikes(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	type returnVals struct {
+		UserID  uuid.UUID `json:"user_id"`
+		LikedAt time.Time `json:"liked_at"`
+	}
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, "Chirp id is not valid")
+		return
+	}
+	page, err := parsePageParams(r)
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if _, err := cfg.db.GetChirpByID(r.Context(), chirpID); err != nil {
+		if err == sql.ErrNoRows {
+			_ = respondWithError(w, http.StatusNotFound, "the chirp does not exist")
+			return
+		}
+		log.Printf("Failed to get chirp by id: %v\n", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	cursorCreatedAt, cursorID := page.cursorArgs()
+	likes, err := cfg.db.GetChirpLikes(r.Context(), database.GetChirpLikesParams{
+		ChirpID:         chirpID,
+		BeforeCreatedAt: cursorCreatedAt,
+		BeforeID:        cursorID,
+		PageLimit:       page.queryLimit(),
+	})
+	if err != nil {
+		log.Printf("failed to get chirp likes: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	if len(likes) > page.Limit {
+		likes = likes[:page.Limit]
+		last := likes[len(likes)-1]
+		setNextLink(w, r, pageCursor{CreatedAt: last.CreatedAt, ID: last.UserID})
+	}
+	data := []returnVals{}
+	for _, l := range likes {
+		data = append(data, returnVals{
+			UserID:  l.UserID,
+			LikedAt: l.CreatedAt,
+		})
+	}
+	_ = respondWithJSON(w, http.StatusOK, data)
+}
+
+// handlerGetUserLikes lists the chirps a user liked, most recently liked
+// first.
+func (cfg *apiConfig) handlerGetUserLikes(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, "user id is not valid")
+		return
+	}
+	page, err := parsePageParams(r)
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	cursorCreatedAt, cursorID := page.cursorArgs()
+	likes, err := cfg.db.GetUserLikes(r.Context(), database.GetUserLikesParams{
+		UserID:          userID,
+		BeforeCreatedAt: cursorCreatedAt,
+		BeforeID:        cursorID,
+		ViewerID:        viewerID(r.Context()),
+		PageLimit:       page.queryLimit(),
+	})
+	if err != nil {
+		log.Printf("failed to get user likes: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	if len(likes) > page.Limit {
+		likes = likes[:page.Limit]
+		last := likes[len(likes)-1]
+		setNextLink(w, r, pageCursor{CreatedAt: last.LikedAt, ID: last.Chirp.ID})
+	}
+	chirps := make([]database.Chirp, 0, len(likes))
+	for _, l := range likes {
+		chirps = append(chirps, l.Chirp)
+	}
+	data, err := cfg.chirpsJSON(r.Context(), chirps)
+	if err != nil {
+		log.Printf("failed to load chirp details: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	_ = respondWithJSON(w, http.StatusOK, data)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Specialized101/chirpy/internal/database"
+	"github.com/Specialized101/chirpy/internal/moderation"
+	"github.com/google/uuid"
+)
+
+// handlerUpdateChirp lets the author edit a chirp for cfg.chirpEditWindow
+// after posting it. The previous body is kept as a revision.
+func (cfg *apiConfig) handlerUpdateChirp(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	type reqParams struct {
+		Body string `json:"body"`
+	}
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, "Chirp id is not valid")
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := reqParams{}
+	if err := decoder.Decode(&params); err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, "request body is not valid JSON")
+		return
+	}
+	if msg := cfg.validateChirpBody(params.Body); msg != "" {
+		_ = respondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
+	checked := cfg.moderation.Check(params.Body)
+	if checked.Action == moderation.Reject {
+		_ = respondWithError(w, http.StatusBadRequest, rejectedChirpMessage)
+		return
+	}
+	chirp, err := cfg.db.GetChirpByID(r.Context(), chirpID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			_ = respondWithError(w, http.StatusNotFound, "the chirp does not exist")
+			return
+		}
+		log.Printf("Failed to get chirp by id: %v\n", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	user, _ := userFromContext(r.Context())
+	if chirp.UserID != user.ID {
+		_ = respondWithError(w, http.StatusForbidden, "cannot edit chirps of other users")
+		return
+	}
+	if chirp.RechirpOfID.Valid {
+		_ = respondWithError(w, http.StatusBadRequest, "rechirps cannot be edited")
+		return
+	}
+	if time.Since(chirp.CreatedAt) > cfg.chirpEditWindow {
+		_ = respondWithError(w, http.StatusForbidden, "the chirp can no longer be edited")
+		return
+	}
+
+	if checked.Text != chirp.Body {
+		chirp, err = cfg.db.UpdateChirp(r.Context(), database.UpdateChirpParams{
+			RevisionID: uuid.New(),
+			UpdatedAt:  time.Now().UTC(),
+			ID:         chirp.ID,
+			Body:       checked.Text,
+		})
+		if err != nil {
+			log.Printf("failed to update chirp: %v", err)
+			_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+			return
+		}
+		cfg.indexChirp(r.Context(), chirp)
+		cfg.flagChirp(r.Context(), chirp, checked)
+	}
+	data, err := cfg.chirpJSON(r.Context(), chirp)
+	if err != nil {
+		log.Printf("failed to load chirp details: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	_ = respondWithJSON(w, http.StatusOK, data)
+}
+
+// handlerGetChirpRevisions lists the previous bodies of a chirp, most
+// recently replaced first.
+func (cfg *apiConfig) handlerGetChirpRevisions(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	type returnVals struct {
+		ID         uuid.UUID `json:"id"`
+		Body       string    `json:"body"`
+		ReplacedAt time.Time `json:"replaced_at"`
+	}
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, "Chirp id is not valid")
+		return
+	}
+	page, err := parsePageParams(r)
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if _, err := cfg.db.GetChirpByID(r.Context(), chirpID); err != nil {
+		if err == sql.ErrNoRows {
+			_ = respondWithError(w, http.StatusNotFound, "the chirp does not exist")
+			return
+		}
+		log.Printf("Failed to get chirp by id: %v\n", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	cursorReplacedAt, cursorID := page.cursorArgs()
+	revisions, err := cfg.db.GetChirpRevisions(r.Context(), database.GetChirpRevisionsParams{
+		ChirpID:          chirpID,
+		BeforeReplacedAt: cursorReplacedAt,
+		BeforeID:         cursorID,
+		PageLimit:        page.queryLimit(),
+	})
+	if err != nil {
+		log.Printf("failed to get chirp revisions: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	if len(revisions) > page.Limit {
+		revisions = revisions[:page.Limit]
+		last := revisions[len(revisions)-1]
+		setNextLink(w, r, pageCursor{CreatedAt: last.ReplacedAt, ID: last.ID})
+	}
+	data := []returnVals{}
+	for _, rev := range revisions {
+		data = append(data, returnVals{
+			ID:         rev.ID,
+			Body:       rev.Body,
+			ReplacedAt: rev.ReplacedAt,
+		})
+	}
+	_ = respondWithJSON(w, http.StatusOK, data)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/Specialized101/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// handlerGetChirpThread returns a chirp with the chirps it replies to, root
+// first, and a page of every reply under it. Replies come oldest first with
+// their in_reply_to, so a client can build the tree as it reads them.
+func (cfg *apiConfig) handlerGetChirpThread(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	type returnVals struct {
+		Ancestors []chirpJSON `json:"ancestors"`
+		Chirp     chirpJSON   `json:"chirp"`
+		Replies   []chirpJSON `json:"replies"`
+	}
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, "Chirp id is not valid")
+		return
+	}
+	page, err := parsePageParams(r)
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	path, err := cfg.db.GetChirpPath(r.Context(), chirpID)
+	if err != nil {
+		log.Printf("failed to get chirp path: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	if len(path) == 0 {
+		_ = respondWithError(w, http.StatusNotFound, "the chirp does not exist")
+		return
+	}
+	cursorCreatedAt, cursorID := page.cursorArgs()
+	replies, err := cfg.db.GetChirpDescendants(r.Context(), database.GetChirpDescendantsParams{
+		ChirpID:        chirpID,
+		AfterCreatedAt: cursorCreatedAt,
+		AfterID:        cursorID,
+		PageLimit:      page.queryLimit(),
+	})
+	if err != nil {
+		log.Printf("failed to get chirp replies: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	if len(replies) > page.Limit {
+		replies = replies[:page.Limit]
+		last := replies[len(replies)-1]
+		setNextLink(w, r, pageCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	data, err := cfg.chirpsJSON(r.Context(), append(path, replies...))
+	if err != nil {
+		log.Printf("failed to load chirp details: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	_ = respondWithJSON(w, http.StatusOK, returnVals{
+		Ancestors: data[:len(path)-1],
+		Chirp:     data[len(path)-1],
+		Replies:   data[len(path):],
+	})
+}
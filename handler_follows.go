@@ -0,0 +1,198 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Specialized101/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) handlerFollowUser(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	user, _ := userFromContext(r.Context())
+	followeeID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, "user id is not valid")
+		return
+	}
+	if followeeID == user.ID {
+		_ = respondWithError(w, http.StatusBadRequest, "cannot follow yourself")
+		return
+	}
+	if _, err := cfg.db.GetUserByID(r.Context(), followeeID); err != nil {
+		if err == sql.ErrNoRows {
+			_ = respondWithError(w, http.StatusNotFound, "the user does not exist")
+			return
+		}
+		log.Printf("failed to get user by id: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	blocked, err := cfg.db.IsBlocked(r.Context(), database.IsBlockedParams{
+		BlockerID: followeeID,
+		BlockedID: user.ID,
+	})
+	if err != nil {
+		log.Printf("failed to check for a block: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	if blocked {
+		_ = respondWithError(w, http.StatusForbidden, "cannot follow a user who blocked you")
+		return
+	}
+	err = cfg.db.CreateFollow(r.Context(), database.CreateFollowParams{
+		FollowerID: user.ID,
+		FolloweeID: followeeID,
+		CreatedAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		log.Printf("failed to create follow: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnfollowUser(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	user, _ := userFromContext(r.Context())
+	followeeID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, "user id is not valid")
+		return
+	}
+	err = cfg.db.DeleteFollow(r.Context(), database.DeleteFollowParams{
+		FollowerID: user.ID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		log.Printf("failed to delete follow: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerGetFollowers(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	type returnVals struct {
+		UserID     uuid.UUID `json:"user_id"`
+		FollowedAt time.Time `json:"followed_at"`
+	}
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, "user id is not valid")
+		return
+	}
+	page, err := parsePageParams(r)
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	cursorCreatedAt, cursorID := page.cursorArgs()
+	followers, err := cfg.db.GetFollowers(r.Context(), database.GetFollowersParams{
+		UserID:          userID,
+		BeforeCreatedAt: cursorCreatedAt,
+		BeforeID:        cursorID,
+		PageLimit:       page.queryLimit(),
+	})
+	if err != nil {
+		log.Printf("failed to get followers: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	if len(followers) > page.Limit {
+		followers = followers[:page.Limit]
+		last := followers[len(followers)-1]
+		setNextLink(w, r, pageCursor{CreatedAt: last.CreatedAt, ID: last.FollowerID})
+	}
+	data := []returnVals{}
+	for _, f := range followers {
+		data = append(data, returnVals{
+			UserID:     f.FollowerID,
+			FollowedAt: f.CreatedAt,
+		})
+	}
+	_ = respondWithJSON(w, http.StatusOK, data)
+}
+
+func (cfg *apiConfig) handlerGetFollowing(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	type returnVals struct {
+		UserID     uuid.UUID `json:"user_id"`
+		FollowedAt time.Time `json:"followed_at"`
+	}
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, "user id is not valid")
+		return
+	}
+	page, err := parsePageParams(r)
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	cursorCreatedAt, cursorID := page.cursorArgs()
+	following, err := cfg.db.GetFollowing(r.Context(), database.GetFollowingParams{
+		UserID:          userID,
+		BeforeCreatedAt: cursorCreatedAt,
+		BeforeID:        cursorID,
+		PageLimit:       page.queryLimit(),
+	})
+	if err != nil {
+		log.Printf("failed to get following: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	if len(following) > page.Limit {
+		following = following[:page.Limit]
+		last := following[len(following)-1]
+		setNextLink(w, r, pageCursor{CreatedAt: last.CreatedAt, ID: last.FolloweeID})
+	}
+	data := []returnVals{}
+	for _, f := range following {
+		data = append(data, returnVals{
+			UserID:     f.FolloweeID,
+			FollowedAt: f.CreatedAt,
+		})
+	}
+	_ = respondWithJSON(w, http.StatusOK, data)
+}
+
+func (cfg *apiConfig) handlerGetTimeline(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	user, _ := userFromContext(r.Context())
+	page, err := parsePageParams(r)
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	cursorCreatedAt, cursorID := page.cursorArgs()
+	chirps, err := cfg.db.GetTimeline(r.Context(), database.GetTimelineParams{
+		UserID:          user.ID,
+		BeforeCreatedAt: cursorCreatedAt,
+		BeforeID:        cursorID,
+		PageLimit:       page.queryLimit(),
+	})
+	if err != nil {
+		log.Printf("failed to get timeline: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	if len(chirps) > page.Limit {
+		chirps = chirps[:page.Limit]
+		last := chirps[len(chirps)-1]
+		setNextLink(w, r, pageCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+	data, err := cfg.chirpsJSON(r.Context(), chirps)
+	if err != nil {
+		log.Printf("failed to load chirp details: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	_ = respondWithJSON(w, http.StatusOK, data)
+}
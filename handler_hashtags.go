@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Specialized101/chirpy/internal/database"
+	"github.com/Specialized101/chirpy/internal/hashtag"
+)
+
+const defaultTrendingLimit = 10
+
+// handlerGetHashtagChirps lists the chirps with a hashtag, newest first. The
+// tag may be given with its '#', encoded as %23, and in any case.
+func (cfg *apiConfig) handlerGetHashtagChirps(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	tag, ok := hashtag.Normalize(strings.TrimPrefix(r.PathValue("tag"), "#"))
+	if !ok {
+		_ = respondWithError(w, http.StatusBadRequest, "hashtag is not valid")
+		return
+	}
+	page, err := parsePageParams(r)
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	cursorCreatedAt, cursorID := page.cursorArgs()
+	chirps, err := cfg.db.GetHashtagChirps(r.Context(), database.GetHashtagChirpsParams{
+		Tag:             tag,
+		BeforeCreatedAt: cursorCreatedAt,
+		BeforeID:        cursorID,
+		ViewerID:        viewerID(r.Context()),
+		PageLimit:       page.queryLimit(),
+	})
+	if err != nil {
+		log.Printf("failed to get hashtag chirps: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	if len(chirps) > page.Limit {
+		chirps = chirps[:page.Limit]
+		last := chirps[len(chirps)-1]
+		setNextLink(w, r, pageCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+	data, err := cfg.chirpsJSON(r.Context(), chirps)
+	if err != nil {
+		log.Printf("failed to load chirp details: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	_ = respondWithJSON(w, http.StatusOK, data)
+}
+
+// handlerGetTrendingHashtags ranks the hashtags by how many chirps used them
+// over the last cfg.trendingWindow.
+func (cfg *apiConfig) handlerGetTrendingHashtags(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	type returnVals struct {
+		Tag        string `json:"tag"`
+		ChirpCount int64  `json:"chirp_count"`
+	}
+	limit := defaultTrendingLimit
+	if s := r.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxPageLimit {
+			_ = respondWithError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxPageLimit))
+			return
+		}
+		limit = n
+	}
+	trending, err := cfg.db.GetTrendingHashtags(r.Context(), database.GetTrendingHashtagsParams{
+		Since:     time.Now().UTC().Add(-cfg.trendingWindow),
+		PageLimit: int32(limit),
+	})
+	if err != nil {
+		log.Printf("failed to get trending hashtags: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	data := []returnVals{}
+	for _, t := range trending {
+		data = append(data, returnVals{
+			Tag:        t.Tag,
+			ChirpCount: t.ChirpCount,
+		})
+	}
+	_ = respondWithJSON(w, http.StatusOK, data)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/Specialized101/chirpy/internal/database"
+)
+
+// handlerGetMyMentions lists the chirps that mention the caller by their
+// username, newest first.
+func (cfg *apiConfig) handlerGetMyMentions(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	user, _ := userFromContext(r.Context())
+	page, err := parsePageParams(r)
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	cursorCreatedAt, cursorID := page.cursorArgs()
+	chirps, err := cfg.db.GetUserMentions(r.Context(), database.GetUserMentionsParams{
+		UserID:          user.ID,
+		BeforeCreatedAt: cursorCreatedAt,
+		BeforeID:        cursorID,
+		PageLimit:       page.queryLimit(),
+	})
+	if err != nil {
+		log.Printf("failed to get mentions: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	if len(chirps) > page.Limit {
+		chirps = chirps[:page.Limit]
+		last := chirps[len(chirps)-1]
+		setNextLink(w, r, pageCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+	data, err := cfg.chirpsJSON(r.Context(), chirps)
+	if err != nil {
+		log.Printf("failed to load chirp details: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	_ = respondWithJSON(w, http.StatusOK, data)
+}
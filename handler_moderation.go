@@ -0,0 +1,157 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Specialized101/chirpy/internal/database"
+	"github.com/Specialized101/chirpy/internal/moderation"
+)
+
+type moderationRuleJSON struct {
+	Word   string            `json:"word"`
+	Action moderation.Action `json:"action"`
+}
+
+func moderationRulesJSON(rules []moderation.Rule) []moderationRuleJSON {
+	data := []moderationRuleJSON{}
+	for _, r := range rules {
+		data = append(data, moderationRuleJSON{Word: r.Word, Action: r.Action})
+	}
+	return data
+}
+
+// handlerGetModerationRules lists the rules in force, from the words file and
+// the database.
+func (cfg *apiConfig) handlerGetModerationRules(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	_ = respondWithJSON(w, http.StatusOK, moderationRulesJSON(cfg.moderation.Rules()))
+}
+
+// handlerPutModerationRule adds a word to the database list, or changes its
+// action, and puts it in force on this server right away. The others pick it
+// up on their next reload.
+func (cfg *apiConfig) handlerPutModerationRule(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	type reqParams struct {
+		Action string `json:"action"`
+	}
+	word := moderation.Normalize(r.PathValue("word"))
+	if word == "" {
+		_ = respondWithError(w, http.StatusBadRequest, "word must have letters or digits")
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := reqParams{}
+	if err := decoder.Decode(&params); err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, "request body is not valid JSON")
+		return
+	}
+	action, err := moderation.ParseAction(params.Action)
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	rule, err := cfg.db.UpsertModerationRule(r.Context(), database.UpsertModerationRuleParams{
+		Word:      word,
+		Action:    string(action),
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		log.Printf("failed to save moderation rule: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	if err := cfg.loadModerationRules(r.Context()); err != nil {
+		log.Printf("failed to reload the moderation rules: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	_ = respondWithJSON(w, http.StatusOK, moderationRuleJSON{Word: rule.Word, Action: moderation.Action(rule.Action)})
+}
+
+// handlerDeleteModerationRule removes a word from the database list. Words of
+// the words file can only be removed from the file.
+func (cfg *apiConfig) handlerDeleteModerationRule(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	_, err := cfg.db.DeleteModerationRule(r.Context(), moderation.Normalize(r.PathValue("word")))
+	if err == sql.ErrNoRows {
+		_ = respondWithError(w, http.StatusNotFound, "the word is not in the moderation rules")
+		return
+	}
+	if err != nil {
+		log.Printf("failed to delete moderation rule: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	if err := cfg.loadModerationRules(r.Context()); err != nil {
+		log.Printf("failed to reload the moderation rules: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerReloadModerationRules reads the words file and the database list
+// again, after the file was edited for instance, and returns the rules now in
+// force.
+func (cfg *apiConfig) handlerReloadModerationRules(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	if err := cfg.loadModerationRules(r.Context()); err != nil {
+		log.Printf("failed to reload the moderation rules: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	_ = respondWithJSON(w, http.StatusOK, moderationRulesJSON(cfg.moderation.Rules()))
+}
+
+// handlerGetFlaggedChirps lists the chirps using flagged words, most recently
+// flagged first, with the words they use.
+func (cfg *apiConfig) handlerGetFlaggedChirps(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	type returnVals struct {
+		Chirp     chirpJSON `json:"chirp"`
+		Words     []string  `json:"words"`
+		FlaggedAt time.Time `json:"flagged_at"`
+	}
+	page, err := parsePageParams(r)
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	cursorFlaggedAt, cursorID := page.cursorArgs()
+	flagged, err := cfg.db.GetFlaggedChirps(r.Context(), database.GetFlaggedChirpsParams{
+		BeforeFlaggedAt: cursorFlaggedAt,
+		BeforeID:        cursorID,
+		PageLimit:       page.queryLimit(),
+	})
+	if err != nil {
+		log.Printf("failed to get flagged chirps: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	if len(flagged) > page.Limit {
+		flagged = flagged[:page.Limit]
+		last := flagged[len(flagged)-1]
+		setNextLink(w, r, pageCursor{CreatedAt: last.FlaggedAt, ID: last.Chirp.ID})
+	}
+
+	chirps := make([]database.Chirp, 0, len(flagged))
+	for _, f := range flagged {
+		chirps = append(chirps, f.Chirp)
+	}
+	details, err := cfg.chirpsJSON(r.Context(), chirps)
+	if err != nil {
+		log.Printf("failed to load chirp details: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	data := []returnVals{}
+	for i, f := range flagged {
+		data = append(data, returnVals{Chirp: details[i], Words: f.Words, FlaggedAt: f.FlaggedAt})
+	}
+	_ = respondWithJSON(w, http.StatusOK, data)
+}
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Specialized101/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// reportReasons are the reason codes a report can give. The reports table
+// checks the same list.
+var reportReasons = []string{"spam", "harassment", "hate", "violence", "sexual_content", "self_harm", "impersonation", "other"}
+
+const maxReportDetailsLength = 1000
+
+// The resolutions of a report, see handlerResolveReport.
+const (
+	resolutionDismiss     = "dismiss"
+	resolutionHideChirp   = "hide_chirp"
+	resolutionSuspendUser = "suspend_user"
+)
+
+// reportJSON is a report as the API returns it. ChirpBody is the chirp as it
+// was when reported, ChirpID is null for a report about a user, or once the
+// chirp is deleted.
+type reportJSON struct {
+	ID             uuid.UUID  `json:"id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	ReporterID     *uuid.UUID `json:"reporter_id"`
+	UserID         uuid.UUID  `json:"user_id"`
+	ChirpID        *uuid.UUID `json:"chirp_id"`
+	ChirpBody      *string    `json:"chirp_body"`
+	Reason         string     `json:"reason"`
+	Details        string     `json:"details"`
+	Status         string     `json:"status"`
+	ClaimedBy      *uuid.UUID `json:"claimed_by"`
+	ClaimedAt      *time.Time `json:"claimed_at"`
+	ResolvedBy     *uuid.UUID `json:"resolved_by"`
+	ResolvedAt     *time.Time `json:"resolved_at"`
+	Resolution     *string    `json:"resolution"`
+	ResolutionNote string     `json:"resolution_note"`
+}
+
+func newReportJSON(report database.Report) reportJSON {
+	return reportJSON{
+		ID:             report.ID,
+		CreatedAt:      report.CreatedAt,
+		UpdatedAt:      report.UpdatedAt,
+		ReporterID:     nullableUUID(report.ReporterID),
+		UserID:         report.UserID,
+		ChirpID:        nullableUUID(report.ChirpID),
+		ChirpBody:      nullableString(report.ChirpBody),
+		Reason:         report.Reason,
+		Details:        report.Details,
+		Status:         reportStatus(report),
+		ClaimedBy:      nullableUUID(report.ClaimedBy),
+		ClaimedAt:      nullableTime(report.ClaimedAt),
+		ResolvedBy:     nullableUUID(report.ResolvedBy),
+		ResolvedAt:     nullableTime(report.ResolvedAt),
+		Resolution:     nullableString(report.Resolution),
+		ResolutionNote: report.ResolutionNote,
+	}
+}
+
+// reportStatus is open until a moderator claims the report, then claimed
+// until they resolve it.
+func reportStatus(report database.Report) string {
+	switch {
+	case report.ResolvedAt.Valid:
+		return "resolved"
+	case report.ClaimedAt.Valid:
+		return "claimed"
+	default:
+		return "open"
+	}
+}
+
+// createReport saves the report of the caller about a user, and about one of
+// their chirps when chirp is not nil.
+func (cfg *apiConfig) createReport(w http.ResponseWriter, r *http.Request, userID uuid.UUID, chirp *database.Chirp) {
+	type reqParams struct {
+		Reason  string `json:"reason"`
+		Details string `json:"details"`
+	}
+	reporter, _ := userFromContext(r.Context())
+	if userID == reporter.ID {
+		_ = respondWithError(w, http.StatusBadRequest, "cannot report yourself")
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := reqParams{}
+	if err := decoder.Decode(&params); err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, "request body is not valid JSON")
+		return
+	}
+	if !slices.Contains(reportReasons, params.Reason) {
+		_ = respondWithError(w, http.StatusBadRequest, "reason must be one of "+strings.Join(reportReasons, ", "))
+		return
+	}
+	if len(params.Details) > maxReportDetailsLength {
+		_ = respondWithError(w, http.StatusBadRequest, fmt.Sprintf("details must be at most %d bytes", maxReportDetailsLength))
+		return
+	}
+	arg := database.CreateReportParams{
+		ID:         uuid.New(),
+		CreatedAt:  time.Now().UTC(),
+		ReporterID: uuid.NullUUID{UUID: reporter.ID, Valid: true},
+		UserID:     userID,
+		Reason:     params.Reason,
+		Details:    params.Details,
+	}
+	if chirp != nil {
+		arg.ChirpID = uuid.NullUUID{UUID: chirp.ID, Valid: true}
+		arg.ChirpBody = sql.NullString{String: chirp.Body, Valid: true}
+	}
+	report, err := cfg.db.CreateReport(r.Context(), arg)
+	if err != nil {
+		log.Printf("failed to create report: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	_ = respondWithJSON(w, http.StatusCreated, newReportJSON(report))
+}
+
+func (cfg *apiConfig) handlerReportChirp(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, "Chirp id is not valid")
+		return
+	}
+	chirp, err := cfg.db.GetChirpByID(r.Context(), chirpID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			_ = respondWithError(w, http.StatusNotFound, "the chirp does not exist")
+			return
+		}
+		log.Printf("Failed to get chirp by id: %v\n", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	if chirp.RechirpOfID.Valid {
+		_ = respondWithError(w, http.StatusBadRequest, "report the rechirped chirp instead")
+		return
+	}
+	cfg.createReport(w, r, chirp.UserID, &chirp)
+}
+
+func (cfg *apiConfig) handlerReportUser(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, "user id is not valid")
+		return
+	}
+	if _, err := cfg.db.GetUserByID(r.Context(), userID); err != nil {
+		if err == sql.ErrNoRows {
+			_ = respondWithError(w, http.StatusNotFound, "the user does not exist")
+			return
+		}
+		log.Printf("failed to get user by id: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	cfg.createReport(w, r, userID, nil)
+}
+
+// handlerGetReports is the moderation queue: the reports with the status
+// query parameter, open by default, oldest first.
+func (cfg *apiConfig) handlerGetReports(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = "open"
+	}
+	if status != "open" && status != "claimed" && status != "resolved" {
+		_ = respondWithError(w, http.StatusBadRequest, "status must be open, claimed or resolved")
+		return
+	}
+	page, err := parsePageParams(r)
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	cursorCreatedAt, cursorID := page.cursorArgs()
+	reports, err := cfg.db.GetReports(r.Context(), database.GetReportsParams{
+		Status:         status,
+		AfterCreatedAt: cursorCreatedAt,
+		AfterID:        cursorID,
+		PageLimit:      page.queryLimit(),
+	})
+	if err != nil {
+		log.Printf("failed to get reports: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	if len(reports) > page.Limit {
+		reports = reports[:page.Limit]
+		last := reports[len(reports)-1]
+		setNextLink(w, r, pageCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+	data := []reportJSON{}
+	for _, report := range reports {
+		data = append(data, newReportJSON(report))
+	}
+	_ = respondWithJSON(w, http.StatusOK, data)
+}
+
+// handlerClaimReport assigns a report to the caller, so two moderators do not
+// handle it at once. Claiming a report again is a no-op.
+func (cfg *apiConfig) handlerClaimReport(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	moderator, _ := userFromContext(r.Context())
+	reportID, err := uuid.Parse(r.PathValue("reportID"))
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, "report id is not valid")
+		return
+	}
+	report, err := cfg.db.ClaimReport(r.Context(), database.ClaimReportParams{
+		ModeratorID: moderator.ID,
+		ClaimedAt:   time.Now().UTC(),
+		ID:          reportID,
+	})
+	if err == sql.ErrNoRows {
+		cfg.respondWithReportConflict(w, r, reportID)
+		return
+	}
+	if err != nil {
+		log.Printf("failed to claim report: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	_ = respondWithJSON(w, http.StatusOK, newReportJSON(report))
+}
+
+// handlerResolveReport applies the outcome a moderator chose for a report and
+// records it, with their id and an optional note. dismiss leaves things as
+// they are, hide_chirp turns the reported chirp into a tombstone, and
+// suspend_user suspends the reported user until the suspension is lifted,
+// with the note as the reason.
+func (cfg *apiConfig) handlerResolveReport(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	type reqParams struct {
+		Resolution string `json:"resolution"`
+		Note       string `json:"note"`
+	}
+	moderator, _ := userFromContext(r.Context())
+	reportID, err := uuid.Parse(r.PathValue("reportID"))
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, "report id is not valid")
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := reqParams{}
+	if err := decoder.Decode(&params); err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, "request body is not valid JSON")
+		return
+	}
+	switch params.Resolution {
+	case resolutionDismiss, resolutionHideChirp, resolutionSuspendUser:
+	default:
+		_ = respondWithError(w, http.StatusBadRequest, fmt.Sprintf("resolution must be %s, %s or %s", resolutionDismiss, resolutionHideChirp, resolutionSuspendUser))
+		return
+	}
+	if len(params.Note) > maxReportDetailsLength {
+		_ = respondWithError(w, http.StatusBadRequest, fmt.Sprintf("note must be at most %d bytes", maxReportDetailsLength))
+		return
+	}
+	report, err := cfg.db.GetReportByID(r.Context(), reportID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			_ = respondWithError(w, http.StatusNotFound, "the report does not exist")
+			return
+		}
+		log.Printf("failed to get report by id: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	if report.ResolvedAt.Valid || (report.ClaimedBy.Valid && report.ClaimedBy.UUID != moderator.ID) {
+		cfg.respondWithReportConflict(w, r, reportID)
+		return
+	}
+
+	// The outcome is applied before it is recorded: both are safe to apply
+	// again if recording fails and the moderator retries.
+	switch params.Resolution {
+	case resolutionHideChirp:
+		if !report.ChirpID.Valid {
+			_ = respondWithError(w, http.StatusBadRequest, "the report is not about a chirp that still exists")
+			return
+		}
+		// A tombstone rather than deleteChirp, so the chirp stays next to
+		// the report.
+		err = cfg.db.TombstoneChirp(r.Context(), database.TombstoneChirpParams{
+			ID:        report.ChirpID.UUID,
+			DeletedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		})
+		if err != nil {
+			log.Printf("failed to hide chirp %s: %v", report.ChirpID.UUID, err)
+			_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+			return
+		}
+	case resolutionSuspendUser:
+		user, err := cfg.db.GetUserByID(r.Context(), report.UserID)
+		if err != nil {
+			log.Printf("failed to get user by id: %v", err)
+			_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+			return
+		}
+		if !canSuspend(moderator, user) {
+			_ = respondWithError(w, http.StatusForbidden, "only admins can suspend moderators and admins")
+			return
+		}
+		// A suspension already in force, a ban maybe, is left as is.
+		if userSuspended(user) {
+			break
+		}
+		reason := params.Note
+		if reason == "" {
+			reason = "reported for " + report.Reason
+		}
+		_, err = cfg.suspendUser(r.Context(), database.SuspendUserParams{
+			SuspendedAt:      time.Now().UTC(),
+			SuspensionReason: reason,
+			ID:               user.ID,
+		})
+		if err != nil {
+			log.Printf("failed to suspend user %s: %v", user.ID, err)
+			_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+			return
+		}
+	}
+
+	report, err = cfg.db.ResolveReport(r.Context(), database.ResolveReportParams{
+		ModeratorID:    moderator.ID,
+		ResolvedAt:     time.Now().UTC(),
+		Resolution:     params.Resolution,
+		ResolutionNote: params.Note,
+		ID:             reportID,
+	})
+	if err == sql.ErrNoRows {
+		cfg.respondWithReportConflict(w, r, reportID)
+		return
+	}
+	if err != nil {
+		log.Printf("failed to resolve report: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	_ = respondWithJSON(w, http.StatusOK, newReportJSON(report))
+}
+
+// respondWithReportConflict explains why the caller cannot claim or resolve
+// a report.
+func (cfg *apiConfig) respondWithReportConflict(w http.ResponseWriter, r *http.Request, reportID uuid.UUID) {
+	report, err := cfg.db.GetReportByID(r.Context(), reportID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			_ = respondWithError(w, http.StatusNotFound, "the report does not exist")
+			return
+		}
+		log.Printf("failed to get report by id: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	if report.ResolvedAt.Valid {
+		_ = respondWithError(w, http.StatusConflict, "the report is already resolved")
+		return
+	}
+	_ = respondWithError(w, http.StatusConflict, "the report is claimed by another moderator")
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/Specialized101/chirpy/internal/auth"
+	"github.com/Specialized101/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// handlerSetUserRole makes a user a moderator or an admin, or a user again.
+// Admins cannot change their own role, so there is always one left.
+func (cfg *apiConfig) handlerSetUserRole(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	type reqParams struct {
+		Role string `json:"role"`
+	}
+	type returnVals struct {
+		ID   uuid.UUID `json:"id"`
+		Role string    `json:"role"`
+	}
+	admin, _ := userFromContext(r.Context())
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, "user id is not valid")
+		return
+	}
+	if userID == admin.ID {
+		_ = respondWithError(w, http.StatusBadRequest, "cannot change your own role")
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	params := reqParams{}
+	if err := decoder.Decode(&params); err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, "request body is not valid JSON")
+		return
+	}
+	role, err := auth.ParseRole(params.Role)
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	user, err := cfg.db.SetUserRole(r.Context(), database.SetUserRoleParams{
+		ID:   userID,
+		Role: string(role),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			_ = respondWithError(w, http.StatusNotFound, "the user does not exist")
+			return
+		}
+		log.Printf("failed to set user role: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	_ = respondWithJSON(w, http.StatusOK, returnVals{ID: user.ID, Role: user.Role})
+}
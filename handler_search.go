@@ -0,0 +1,174 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Specialized101/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// maxSearchQueryLength bounds q, which Postgres parses on every request.
+const maxSearchQueryLength = 256
+
+// likeEscaper escapes the LIKE wildcards of a user's search.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// handlerSearch searches the chirps, or the users with type=users.
+//
+// Chirps match q in the web search syntax: every word, "quoted phrases" word
+// for word, "or" between two alternatives, and no -excluded word. They come
+// best ranked first, each with a highlight: the body as HTML with the matches
+// in <mark>. Users match when their username starts with q, with or without
+// the '@', and come in username order.
+func (cfg *apiConfig) handlerSearch(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		_ = respondWithError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+	if len(q) > maxSearchQueryLength {
+		_ = respondWithError(w, http.StatusBadRequest, fmt.Sprintf("q must be at most %d characters", maxSearchQueryLength))
+		return
+	}
+	limit, err := parsePageLimit(r)
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	switch r.URL.Query().Get("type") {
+	case "", "chirps":
+		cfg.searchChirps(w, r, q, limit)
+	case "users":
+		cfg.searchUsers(w, r, q, limit)
+	default:
+		_ = respondWithError(w, http.StatusBadRequest, "type must be chirps or users")
+	}
+}
+
+// searchChirps pages through the results by (rank, id), the order they come
+// in, so the cursor holds the rank of the last chirp instead of a time.
+func (cfg *apiConfig) searchChirps(w http.ResponseWriter, r *http.Request, q string, limit int) {
+	type returnVals struct {
+		chirpJSON
+		Highlight string `json:"highlight"`
+	}
+	params := database.SearchChirpsParams{
+		Query:     q,
+		ViewerID:  viewerID(r.Context()),
+		PageLimit: int32(limit + 1),
+	}
+	if s := r.URL.Query().Get("cursor"); s != "" {
+		key, id, err := decodeKeyset(s)
+		if err != nil {
+			_ = respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		rank, err := strconv.ParseFloat(key, 32)
+		if err != nil {
+			_ = respondWithError(w, http.StatusBadRequest, "cursor is not valid")
+			return
+		}
+		params.BeforeRank = sql.NullFloat64{Float64: rank, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+	rows, err := cfg.db.SearchChirps(r.Context(), params)
+	if err != nil {
+		log.Printf("failed to search chirps: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		setNextPageLink(w, r, encodeKeyset(strconv.FormatFloat(float64(last.Rank), 'g', -1, 32), last.Chirp.ID))
+	}
+
+	chirps := make([]database.Chirp, 0, len(rows))
+	for _, row := range rows {
+		chirps = append(chirps, row.Chirp)
+	}
+	details, err := cfg.chirpsJSON(r.Context(), chirps)
+	if err != nil {
+		log.Printf("failed to load chirp details: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	data := []returnVals{}
+	for i, c := range details {
+		data = append(data, returnVals{chirpJSON: c, Highlight: highlightHTML(rows[i].Headline)})
+	}
+	_ = respondWithJSON(w, http.StatusOK, data)
+}
+
+// searchUsers pages through the results by username, which is unique.
+func (cfg *apiConfig) searchUsers(w http.ResponseWriter, r *http.Request, q string, limit int) {
+	type returnVals struct {
+		ID       uuid.UUID `json:"id"`
+		Username string    `json:"username"`
+	}
+	prefix := strings.ToLower(strings.TrimPrefix(q, "@"))
+	params := database.SearchUsersParams{
+		Pattern:   likeEscaper.Replace(prefix) + "%",
+		PageLimit: int32(limit + 1),
+	}
+	if s := r.URL.Query().Get("cursor"); s != "" {
+		username, _, err := decodeKeyset(s)
+		if err != nil {
+			_ = respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		params.AfterUsername = sql.NullString{String: username, Valid: true}
+	}
+	users, err := cfg.db.SearchUsers(r.Context(), params)
+	if err != nil {
+		log.Printf("failed to search users: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	if len(users) > limit {
+		users = users[:limit]
+		last := users[len(users)-1]
+		setNextPageLink(w, r, encodeKeyset(strings.ToLower(last.Username.String), last.ID))
+	}
+	data := []returnVals{}
+	for _, u := range users {
+		data = append(data, returnVals{ID: u.ID, Username: u.Username.String})
+	}
+	_ = respondWithJSON(w, http.StatusOK, data)
+}
+
+// highlightHTML turns a SearchChirps headline into HTML: the body is escaped
+// and the matches between the markers are wrapped in <mark>. Markers typed in
+// the body itself never leave a tag open.
+func highlightHTML(headline string) string {
+	var b strings.Builder
+	open := false
+	for {
+		i := strings.IndexAny(headline, "\x02\x03")
+		if i < 0 {
+			break
+		}
+		b.WriteString(html.EscapeString(headline[:i]))
+		switch {
+		case headline[i] == '\x02' && !open:
+			b.WriteString("<mark>")
+			open = true
+		case headline[i] == '\x03' && open:
+			b.WriteString("</mark>")
+			open = false
+		}
+		headline = headline[i+1:]
+	}
+	b.WriteString(html.EscapeString(headline))
+	if open {
+		b.WriteString("</mark>")
+	}
+	return b.String()
+}
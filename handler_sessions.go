@@ -0,0 +1,92 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) handlerGetSessions(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	type returnVals struct {
+		ID         uuid.UUID `json:"id"`
+		CreatedAt  time.Time `json:"created_at"`
+		LastUsedAt time.Time `json:"last_used_at"`
+		UserAgent  string    `json:"user_agent"`
+		IpAddress  string    `json:"ip_address"`
+	}
+	user, _ := userFromContext(r.Context())
+	sessions, err := cfg.db.GetActiveSessionsByUserID(r.Context(), user.ID)
+	if err != nil {
+		log.Printf("failed to get sessions: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	data := []returnVals{}
+	for _, s := range sessions {
+		data = append(data, returnVals{
+			ID:         s.ID,
+			CreatedAt:  s.CreatedAt,
+			LastUsedAt: s.LastUsedAt,
+			UserAgent:  s.UserAgent,
+			IpAddress:  s.IpAddress,
+		})
+	}
+	_ = respondWithJSON(w, http.StatusOK, data)
+}
+
+func (cfg *apiConfig) handlerDeleteSession(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	user, _ := userFromContext(r.Context())
+	sessionID, err := uuid.Parse(r.PathValue("sessionID"))
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, "session id is not valid")
+		return
+	}
+	session, err := cfg.db.GetSessionByID(r.Context(), sessionID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			_ = respondWithError(w, http.StatusNotFound, "the session does not exist")
+			return
+		}
+		log.Printf("failed to get session by id: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	// Sessions of other users are reported as missing so their ids don't leak.
+	if session.UserID != user.ID {
+		_ = respondWithError(w, http.StatusNotFound, "the session does not exist")
+		return
+	}
+	if err := cfg.db.RevokeRefreshTokenFamily(r.Context(), session.ID); err != nil {
+		log.Printf("failed to revoke session: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerDeleteSessions logs the user out everywhere by revoking the refresh
+// tokens of every session.
+func (cfg *apiConfig) handlerDeleteSessions(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	user, _ := userFromContext(r.Context())
+	if err := cfg.db.RevokeUserRefreshTokens(r.Context(), user.ID); err != nil {
+		log.Printf("failed to revoke sessions: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Specialized101/chirpy/internal/auth"
+	"github.com/Specialized101/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const maxSuspensionReasonLength = 500
+
+// suspensionJSON is the suspension of a user as the API returns it. Status
+// is active, suspended or banned.
+type suspensionJSON struct {
+	UserID       uuid.UUID  `json:"user_id"`
+	Status       string     `json:"status"`
+	SuspendedAt  *time.Time `json:"suspended_at"`
+	Until        *time.Time `json:"until"`
+	Reason       string     `json:"reason"`
+	ChirpsHidden bool       `json:"chirps_hidden"`
+}
+
+func newSuspensionJSON(user database.User) suspensionJSON {
+	data := suspensionJSON{UserID: user.ID, Status: "active"}
+	if !userSuspended(user) {
+		return data
+	}
+	data.Status = "suspended"
+	if user.Banned {
+		data.Status = "banned"
+	}
+	data.SuspendedAt = nullableTime(user.SuspendedAt)
+	data.Until = nullableTime(user.SuspendedUntil)
+	data.Reason = user.SuspensionReason
+	data.ChirpsHidden = user.ChirpsHidden
+	return data
+}
+
+// canSuspend reports whether caller may suspend user, or lift their
+// suspension. Moderators can only act on users.
+func canSuspend(caller authUser, user database.User) bool {
+	return caller.Role.Includes(auth.RoleAdmin) || !auth.Role(user.Role).Includes(auth.RoleModerator)
+}
+
+// suspendUser suspends a user and signs them out everywhere. Their access
+// tokens stay valid until they expire, for reading only: middlewareAuth
+// rejects their writes.
+func (cfg *apiConfig) suspendUser(ctx context.Context, arg database.SuspendUserParams) (database.User, error) {
+	user, err := cfg.db.SuspendUser(ctx, arg)
+	if err != nil {
+		return database.User{}, err
+	}
+	if err := cfg.db.RevokeUserRefreshTokens(ctx, user.ID); err != nil {
+		return database.User{}, fmt.Errorf("failed to revoke the refresh tokens: %w", err)
+	}
+	return user, nil
+}
+
+// targetUser reads the user of the userID path value for the suspension
+// handlers, and checks the caller may act on them. It responds and returns
+// false when not.
+func (cfg *apiConfig) targetUser(w http.ResponseWriter, r *http.Request) (database.User, bool) {
+	caller, _ := userFromContext(r.Context())
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, "user id is not valid")
+		return database.User{}, false
+	}
+	if userID == caller.ID {
+		_ = respondWithError(w, http.StatusBadRequest, "cannot suspend yourself")
+		return database.User{}, false
+	}
+	user, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			_ = respondWithError(w, http.StatusNotFound, "the user does not exist")
+			return database.User{}, false
+		}
+		log.Printf("failed to get user by id: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return database.User{}, false
+	}
+	if !canSuspend(caller, user) {
+		_ = respondWithError(w, http.StatusForbidden, "only admins can suspend moderators and admins")
+		return database.User{}, false
+	}
+	return user, true
+}
+
+// handlerSuspendUser suspends a user, or bans them, until a given time or
+// until it is lifted, replacing the suspension in force. Banning takes an
+// admin, and always hides the chirps of the user from the chirp listings,
+// search included; a suspension only hides them when asked to. Replacing a
+// ban takes an admin too, as it lifts the ban.
+func (cfg *apiConfig) handlerSuspendUser(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	type reqParams struct {
+		Banned     bool       `json:"banned"`
+		Until      *time.Time `json:"until"`
+		Reason     string     `json:"reason"`
+		HideChirps bool       `json:"hide_chirps"`
+	}
+	caller, _ := userFromContext(r.Context())
+	decoder := json.NewDecoder(r.Body)
+	params := reqParams{}
+	if err := decoder.Decode(&params); err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, "request body is not valid JSON")
+		return
+	}
+	if params.Reason == "" {
+		_ = respondWithError(w, http.StatusBadRequest, "reason is required")
+		return
+	}
+	if len(params.Reason) > maxSuspensionReasonLength {
+		_ = respondWithError(w, http.StatusBadRequest, fmt.Sprintf("reason must be at most %d bytes", maxSuspensionReasonLength))
+		return
+	}
+	until := sql.NullTime{}
+	if params.Until != nil {
+		if !params.Until.After(time.Now()) {
+			_ = respondWithError(w, http.StatusBadRequest, "until must be in the future")
+			return
+		}
+		until = sql.NullTime{Time: params.Until.UTC(), Valid: true}
+	}
+	if params.Banned && !caller.Role.Includes(auth.RoleAdmin) {
+		_ = respondWithError(w, http.StatusForbidden, "only admins can ban users")
+		return
+	}
+	user, ok := cfg.targetUser(w, r)
+	if !ok {
+		return
+	}
+	if user.Banned && !caller.Role.Includes(auth.RoleAdmin) {
+		_ = respondWithError(w, http.StatusForbidden, "only admins can change a ban")
+		return
+	}
+	suspended, err := cfg.suspendUser(r.Context(), database.SuspendUserParams{
+		SuspendedAt:      time.Now().UTC(),
+		SuspendedUntil:   until,
+		SuspensionReason: params.Reason,
+		Banned:           params.Banned,
+		ChirpsHidden:     params.HideChirps || params.Banned,
+		ID:               user.ID,
+	})
+	if err != nil {
+		log.Printf("failed to suspend user %s: %v", user.ID, err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	_ = respondWithJSON(w, http.StatusOK, newSuspensionJSON(suspended))
+}
+
+// handlerLiftSuspension ends the suspension of a user before it runs out.
+// Lifting a ban takes an admin.
+func (cfg *apiConfig) handlerLiftSuspension(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	caller, _ := userFromContext(r.Context())
+	user, ok := cfg.targetUser(w, r)
+	if !ok {
+		return
+	}
+	if user.Banned && !caller.Role.Includes(auth.RoleAdmin) {
+		_ = respondWithError(w, http.StatusForbidden, "only admins can lift a ban")
+		return
+	}
+	lifted, err := cfg.db.LiftSuspension(r.Context(), user.ID)
+	if err != nil {
+		log.Printf("failed to lift the suspension of user %s: %v", user.ID, err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	_ = respondWithJSON(w, http.StatusOK, newSuspensionJSON(lifted))
+}
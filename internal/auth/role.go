@@ -0,0 +1,31 @@
+package auth
+
+import "fmt"
+
+// Role is what a user is allowed to do. Each role includes the ones before
+// it: a moderator can do what a user can, an admin what a moderator can.
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+var roleRanks = map[Role]int{RoleUser: 1, RoleModerator: 2, RoleAdmin: 3}
+
+// ParseRole returns the role named s.
+func ParseRole(s string) (Role, error) {
+	role := Role(s)
+	if _, ok := roleRanks[role]; !ok {
+		return "", fmt.Errorf("role must be %s, %s or %s", RoleUser, RoleModerator, RoleAdmin)
+	}
+	return role, nil
+}
+
+// Includes reports whether r can do what other can. An unknown role includes
+// none.
+func (r Role) Includes(other Role) bool {
+	rank, ok := roleRanks[r]
+	return ok && rank >= roleRanks[other]
+}
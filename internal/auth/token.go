@@ -12,33 +12,49 @@ import (
 	"github.com/google/uuid"
 )
 
-func MakeJWT(userID uuid.UUID, tokenSecret string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
-		Issuer:    "chirpy",
-		Subject:   userID.String(),
-		IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour).UTC()),
+// claims are the claims of an access token. The role of the user travels
+// with the token for clients to read, the routes guarded by a role check it
+// in the database, where a change applies at once.
+type claims struct {
+	jwt.RegisteredClaims
+	Role Role `json:"role,omitempty"`
+}
+
+func MakeJWT(userID uuid.UUID, role Role, tokenSecret string, expiresIn time.Duration) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "chirpy",
+			Subject:   userID.String(),
+			IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn).UTC()),
+		},
+		Role: role,
 	})
 
 	return token.SignedString([]byte(tokenSecret))
 }
 
-func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
-	claims := &jwt.RegisteredClaims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (any, error) {
+// ValidateJWT returns the user and the role of a valid access token. Tokens
+// minted before roles existed have none, they get RoleUser.
+func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, Role, error) {
+	c := &claims{}
+	token, err := jwt.ParseWithClaims(tokenString, c, func(t *jwt.Token) (any, error) {
 		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method")
 		}
 		return []byte(tokenSecret), nil
 	})
 	if err != nil || !token.Valid {
-		return uuid.Nil, fmt.Errorf("invalid token: %w", err)
+		return uuid.Nil, "", fmt.Errorf("invalid token: %w", err)
 	}
-	userID, err := uuid.Parse(claims.Subject)
+	userID, err := uuid.Parse(c.Subject)
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, "", err
+	}
+	if c.Role == "" {
+		return userID, RoleUser, nil
 	}
-	return userID, nil
+	return userID, c.Role, nil
 }
 
 func GetBearerToken(headers http.Header) (string, error) {
@@ -3,6 +3,7 @@ package auth
 import (
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -10,32 +11,69 @@ import (
 func TestJWT(t *testing.T) {
 	uuid1 := uuid.New()
 	cases := []struct {
-		inputID     uuid.UUID
-		inputSecret string
-		expected    uuid.UUID
+		inputID      uuid.UUID
+		inputRole    Role
+		inputSecret  string
+		expected     uuid.UUID
+		expectedRole Role
 	}{
 		{
-			inputID:     uuid1,
-			inputSecret: "secret",
-			expected:    uuid1,
+			inputID:      uuid1,
+			inputRole:    RoleUser,
+			inputSecret:  "secret",
+			expected:     uuid1,
+			expectedRole: RoleUser,
+		},
+		{
+			inputID:      uuid1,
+			inputRole:    RoleAdmin,
+			inputSecret:  "secret",
+			expected:     uuid1,
+			expectedRole: RoleAdmin,
+		},
+		{
+			inputID:      uuid1,
+			inputSecret:  "secret",
+			expected:     uuid1,
+			expectedRole: RoleUser,
 		},
 	}
 
 	for _, c := range cases {
-		tokenString, err := MakeJWT(c.inputID, c.inputSecret)
+		tokenString, err := MakeJWT(c.inputID, c.inputRole, c.inputSecret, time.Hour)
 		if err != nil {
 			t.Errorf("failed to create JWT: %v\n", err)
 			t.Fail()
 			continue
 		}
-		actual, err := ValidateJWT(tokenString, c.inputSecret)
-		if actual != c.expected {
-			t.Errorf("error: %v\nexpected: %v\nreceived: %v", err, c.expected, actual)
+		actual, role, err := ValidateJWT(tokenString, c.inputSecret)
+		if actual != c.expected || role != c.expectedRole {
+			t.Errorf("error: %v\nexpected: %v %v\nreceived: %v %v", err, c.expected, c.expectedRole, actual, role)
 			t.Fail()
 		}
 	}
 }
 
+func TestRoleIncludes(t *testing.T) {
+	cases := []struct {
+		role     Role
+		other    Role
+		expected bool
+	}{
+		{role: RoleAdmin, other: RoleModerator, expected: true},
+		{role: RoleModerator, other: RoleModerator, expected: true},
+		{role: RoleModerator, other: RoleAdmin, expected: false},
+		{role: RoleUser, other: RoleModerator, expected: false},
+		{role: Role("root"), other: RoleUser, expected: false},
+	}
+
+	for _, c := range cases {
+		if actual := c.role.Includes(c.other); actual != c.expected {
+			t.Errorf("%s includes %s: expected %v, received %v", c.role, c.other, c.expected, actual)
+		}
+	}
+}
+
 func TestGetBearerToken(t *testing.T) {
 	headers1 := http.Header{}
 	headers1.Set("Authorization", "Bearer 123456")
@@ -0,0 +1,314 @@
+// Package config loads the server configuration.
+//
+// Values are resolved in this order, later sources overriding earlier ones:
+// built-in defaults, the YAML file given by -config or CHIRPY_CONFIG,
+// environment variables, then command-line flags.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// minSecretLength is the shortest SECRET_KEY accepted. HS256 keys should be
+// at least as long as the 256-bit hash.
+const minSecretLength = 32
+
+type Config struct {
+	Addr       string           `yaml:"addr"`
+	Platform   string           `yaml:"platform"`
+	SecretKey  string           `yaml:"secret_key"`
+	PolkaKey   string           `yaml:"polka_key"`
+	DB         DBConfig         `yaml:"db"`
+	Server     ServerConfig     `yaml:"server"`
+	Auth       AuthConfig       `yaml:"auth"`
+	Chirps     ChirpsConfig     `yaml:"chirps"`
+	Moderation ModerationConfig `yaml:"moderation"`
+}
+
+type DBConfig struct {
+	URL             string        `yaml:"url"`
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+	MigrateOnStart  bool          `yaml:"migrate_on_start"`
+}
+
+type ServerConfig struct {
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout"`
+}
+
+type AuthConfig struct {
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl"`
+}
+
+type ChirpsConfig struct {
+	MaxLength int `yaml:"max_length"`
+	// EditWindow is how long after posting a chirp can be edited, zero
+	// disables editing.
+	EditWindow time.Duration `yaml:"edit_window"`
+	// TrendingWindow is how far back the trending hashtags look.
+	TrendingWindow time.Duration `yaml:"trending_window"`
+}
+
+type ModerationConfig struct {
+	// WordsFile is a word list used on top of the moderation_rules table,
+	// which wins for words in both.
+	WordsFile string `yaml:"words_file"`
+	// ReloadInterval is how often the lists are read again, so changes made
+	// through another server or to the file apply everywhere. Zero only
+	// reloads them on request.
+	ReloadInterval time.Duration `yaml:"reload_interval"`
+}
+
+// Default returns the configuration used for every value that is not set
+// anywhere else. It has no secrets, so it does not pass Validate on its own.
+func Default() Config {
+	return Config{
+		Addr:     "127.0.0.1:8080",
+		Platform: "prod",
+		DB: DBConfig{
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+		},
+		Server: ServerConfig{
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       10 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       120 * time.Second,
+			ShutdownTimeout:   15 * time.Second,
+		},
+		Auth: AuthConfig{
+			AccessTokenTTL:  time.Hour,
+			RefreshTokenTTL: time.Hour,
+		},
+		Chirps: ChirpsConfig{
+			MaxLength:      140,
+			EditWindow:     15 * time.Minute,
+			TrendingWindow: 24 * time.Hour,
+		},
+		Moderation: ModerationConfig{
+			ReloadInterval: time.Minute,
+		},
+	}
+}
+
+// setting is a value that can come from the environment and, unless flag is
+// empty, from the command line.
+type setting struct {
+	env     string
+	flag    string
+	usage   string
+	boolean bool
+	set     func(c *Config, s string) error
+}
+
+// settings lists every environment variable and flag. Secrets have no flag,
+// command lines end up in shell history and process listings.
+var settings = []setting{
+	{env: "ADDR", flag: "addr", usage: "address to listen on", set: setString(func(c *Config) *string { return &c.Addr })},
+	{env: "PLATFORM", flag: "platform", usage: "dev or prod", set: setString(func(c *Config) *string { return &c.Platform })},
+	{env: "SECRET_KEY", usage: "key signing the access tokens", set: setString(func(c *Config) *string { return &c.SecretKey })},
+	{env: "POLKA_KEY", usage: "api key of the Polka webhooks", set: setString(func(c *Config) *string { return &c.PolkaKey })},
+	{env: "DB_URL", flag: "db-url", usage: "Postgres connection string", set: setString(func(c *Config) *string { return &c.DB.URL })},
+	{env: "DB_MAX_OPEN_CONNS", flag: "db-max-open-conns", usage: "maximum open connections", set: setInt(func(c *Config) *int { return &c.DB.MaxOpenConns })},
+	{env: "DB_MAX_IDLE_CONNS", flag: "db-max-idle-conns", usage: "maximum idle connections", set: setInt(func(c *Config) *int { return &c.DB.MaxIdleConns })},
+	{env: "DB_CONN_MAX_LIFETIME", flag: "db-conn-max-lifetime", usage: "maximum lifetime of a connection", set: setDuration(func(c *Config) *time.Duration { return &c.DB.ConnMaxLifetime })},
+	{env: "DB_CONN_MAX_IDLE_TIME", flag: "db-conn-max-idle-time", usage: "maximum idle time of a connection", set: setDuration(func(c *Config) *time.Duration { return &c.DB.ConnMaxIdleTime })},
+	{env: "MIGRATE_ON_START", flag: "migrate-on-start", usage: "apply pending migrations before serving", boolean: true, set: setBool(func(c *Config) *bool { return &c.DB.MigrateOnStart })},
+	{env: "READ_HEADER_TIMEOUT", flag: "read-header-timeout", usage: "time allowed to read request headers", set: setDuration(func(c *Config) *time.Duration { return &c.Server.ReadHeaderTimeout })},
+	{env: "READ_TIMEOUT", flag: "read-timeout", usage: "time allowed to read a whole request", set: setDuration(func(c *Config) *time.Duration { return &c.Server.ReadTimeout })},
+	{env: "WRITE_TIMEOUT", flag: "write-timeout", usage: "time allowed to write a response", set: setDuration(func(c *Config) *time.Duration { return &c.Server.WriteTimeout })},
+	{env: "IDLE_TIMEOUT", flag: "idle-timeout", usage: "time a keep-alive connection may stay idle", set: setDuration(func(c *Config) *time.Duration { return &c.Server.IdleTimeout })},
+	{env: "SHUTDOWN_TIMEOUT", flag: "shutdown-timeout", usage: "time given to in-flight requests on shutdown", set: setDuration(func(c *Config) *time.Duration { return &c.Server.ShutdownTimeout })},
+	{env: "ACCESS_TOKEN_TTL", flag: "access-token-ttl", usage: "lifetime of access tokens", set: setDuration(func(c *Config) *time.Duration { return &c.Auth.AccessTokenTTL })},
+	{env: "REFRESH_TOKEN_TTL", flag: "refresh-token-ttl", usage: "lifetime of refresh tokens", set: setDuration(func(c *Config) *time.Duration { return &c.Auth.RefreshTokenTTL })},
+	{env: "CHIRP_MAX_LENGTH", flag: "chirp-max-length", usage: "maximum length of a chirp body", set: setInt(func(c *Config) *int { return &c.Chirps.MaxLength })},
+	{env: "CHIRP_EDIT_WINDOW", flag: "chirp-edit-window", usage: "how long after posting a chirp can be edited, 0 disables editing", set: setDuration(func(c *Config) *time.Duration { return &c.Chirps.EditWindow })},
+	{env: "TRENDING_WINDOW", flag: "trending-window", usage: "how far back the trending hashtags look", set: setDuration(func(c *Config) *time.Duration { return &c.Chirps.TrendingWindow })},
+	{env: "MODERATION_WORDS_FILE", flag: "moderation-words-file", usage: "word list to moderate chirps with besides the database", set: setString(func(c *Config) *string { return &c.Moderation.WordsFile })},
+	{env: "MODERATION_RELOAD_INTERVAL", flag: "moderation-reload-interval", usage: "how often the moderation word lists are reloaded, 0 disables", set: setDuration(func(c *Config) *time.Duration { return &c.Moderation.ReloadInterval })},
+}
+
+// Resolve resolves the configuration from args and lookupEnv, usually the
+// arguments of a command and os.LookupEnv. It does not validate it, so
+// commands that only need the database can check less. The configuration
+// flags are added to fs, which may already define flags of its own, and the
+// arguments left after the flags are available from fs.Args.
+func Resolve(fs *flag.FlagSet, args []string, lookupEnv func(string) (string, bool)) (Config, error) {
+	configPath := fs.String("config", "", "path of a YAML configuration file (env CHIRPY_CONFIG)")
+	flagValues := map[string]string{}
+	for _, s := range settings {
+		if s.flag == "" {
+			continue
+		}
+		name := s.flag
+		set := func(v string) error {
+			flagValues[name] = v
+			return nil
+		}
+		usage := fmt.Sprintf("%s (env %s)", s.usage, s.env)
+		if s.boolean {
+			fs.BoolFunc(name, usage, set)
+		} else {
+			fs.Func(name, usage, set)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	c := Default()
+	if *configPath == "" {
+		*configPath, _ = lookupEnv("CHIRPY_CONFIG")
+	}
+	if *configPath != "" {
+		if err := c.loadFile(*configPath); err != nil {
+			return Config{}, err
+		}
+	}
+
+	var errs []error
+	for _, s := range settings {
+		if v, ok := lookupEnv(s.env); ok && v != "" {
+			if err := s.set(&c, v); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", s.env, err))
+			}
+		}
+	}
+	for _, s := range settings {
+		if v, ok := flagValues[s.flag]; ok && s.flag != "" {
+			if err := s.set(&c, v); err != nil {
+				errs = append(errs, fmt.Errorf("-%s: %w", s.flag, err))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return Config{}, errors.Join(errs...)
+	}
+	return c, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Validate reports every invalid value at once.
+func (c Config) Validate() error {
+	errs := []error{c.ValidateDatabase()}
+	if c.Addr == "" {
+		errs = append(errs, errors.New("ADDR is required"))
+	}
+	if c.Platform != "dev" && c.Platform != "prod" {
+		errs = append(errs, fmt.Errorf("PLATFORM must be dev or prod, received %q", c.Platform))
+	}
+	if c.SecretKey == "" {
+		errs = append(errs, errors.New("SECRET_KEY is required"))
+	} else if len(c.SecretKey) < minSecretLength {
+		errs = append(errs, fmt.Errorf("SECRET_KEY is too weak, it must be at least %d characters long", minSecretLength))
+	}
+	if c.PolkaKey == "" {
+		errs = append(errs, errors.New("POLKA_KEY is required"))
+	}
+	durations := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"READ_HEADER_TIMEOUT", c.Server.ReadHeaderTimeout},
+		{"READ_TIMEOUT", c.Server.ReadTimeout},
+		{"WRITE_TIMEOUT", c.Server.WriteTimeout},
+		{"IDLE_TIMEOUT", c.Server.IdleTimeout},
+		{"SHUTDOWN_TIMEOUT", c.Server.ShutdownTimeout},
+		{"ACCESS_TOKEN_TTL", c.Auth.AccessTokenTTL},
+		{"REFRESH_TOKEN_TTL", c.Auth.RefreshTokenTTL},
+		{"TRENDING_WINDOW", c.Chirps.TrendingWindow},
+	}
+	for _, d := range durations {
+		if d.value <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive", d.name))
+		}
+	}
+	if c.Chirps.MaxLength <= 0 {
+		errs = append(errs, errors.New("CHIRP_MAX_LENGTH must be positive"))
+	}
+	if c.Chirps.EditWindow < 0 {
+		errs = append(errs, errors.New("CHIRP_EDIT_WINDOW must not be negative"))
+	}
+	if c.Moderation.ReloadInterval < 0 {
+		errs = append(errs, errors.New("MODERATION_RELOAD_INTERVAL must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// ValidateDatabase only checks the settings needed to reach the database.
+func (c Config) ValidateDatabase() error {
+	var errs []error
+	if c.DB.URL == "" {
+		errs = append(errs, errors.New("DB_URL is required"))
+	}
+	if c.DB.MaxOpenConns < 0 || c.DB.MaxIdleConns < 0 {
+		errs = append(errs, errors.New("DB_MAX_OPEN_CONNS and DB_MAX_IDLE_CONNS must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+func setString(field func(*Config) *string) func(*Config, string) error {
+	return func(c *Config, s string) error {
+		*field(c) = s
+		return nil
+	}
+}
+
+func setInt(field func(*Config) *int) func(*Config, string) error {
+	return func(c *Config, s string) error {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", s)
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+func setBool(field func(*Config) *bool) func(*Config, string) error {
+	return func(c *Config, s string) error {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", s)
+		}
+		*field(c) = b
+		return nil
+	}
+}
+
+func setDuration(field func(*Config) *time.Duration) func(*Config, string) error {
+	return func(c *Config, s string) error {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration such as 15s", s)
+		}
+		*field(c) = d
+		return nil
+	}
+}
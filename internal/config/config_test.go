@@ -0,0 +1,160 @@
+package config
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func envFrom(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+}
+
+func validEnv() map[string]string {
+	return map[string]string{
+		"SECRET_KEY": testSecret,
+		"POLKA_KEY":  "polka",
+		"DB_URL":     "postgres://localhost/chirpy",
+	}
+}
+
+// resolve resolves and validates the configuration, like the serve command.
+func resolve(args []string, env map[string]string) (Config, error) {
+	fs := flag.NewFlagSet("chirpy", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	c, err := Resolve(fs, args, envFrom(env))
+	if err != nil {
+		return Config{}, err
+	}
+	return c, c.Validate()
+}
+
+func TestResolveDefaults(t *testing.T) {
+	c, err := resolve(nil, validEnv())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Addr != "127.0.0.1:8080" || c.Platform != "prod" || c.Chirps.MaxLength != 140 {
+		t.Errorf("unexpected defaults: %+v", c)
+	}
+	if c.SecretKey != testSecret || c.DB.URL != "postgres://localhost/chirpy" {
+		t.Errorf("environment was not applied: %+v", c)
+	}
+}
+
+func TestResolvePrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chirpy.yaml")
+	file := `
+addr: 0.0.0.0:9000
+platform: dev
+server:
+  read_timeout: 7s
+  write_timeout: 8s
+chirps:
+  max_length: 280
+`
+	if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	env := validEnv()
+	env["CHIRPY_CONFIG"] = path
+	env["WRITE_TIMEOUT"] = "9s"
+	env["CHIRP_MAX_LENGTH"] = "200"
+
+	c, err := resolve([]string{"-chirp-max-length", "500"}, env)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cases := []struct {
+		name     string
+		actual   any
+		expected any
+	}{
+		{"default", c.Server.IdleTimeout, 120 * time.Second},
+		{"file", c.Addr, "0.0.0.0:9000"},
+		{"file", c.Server.ReadTimeout, 7 * time.Second},
+		{"env over file", c.Server.WriteTimeout, 9 * time.Second},
+		{"flag over env", c.Chirps.MaxLength, 500},
+	}
+	for _, tc := range cases {
+		if tc.actual != tc.expected {
+			t.Errorf("%s: expected %v, received %v", tc.name, tc.expected, tc.actual)
+		}
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		args     []string
+		env      map[string]string
+		expected string
+	}{
+		{
+			name:     "missing secret",
+			env:      map[string]string{"POLKA_KEY": "polka", "DB_URL": "postgres://"},
+			expected: "SECRET_KEY is required",
+		},
+		{
+			name:     "weak secret",
+			env:      map[string]string{"SECRET_KEY": "short", "POLKA_KEY": "polka", "DB_URL": "postgres://"},
+			expected: "SECRET_KEY is too weak",
+		},
+		{
+			name:     "missing database",
+			env:      map[string]string{"SECRET_KEY": testSecret, "POLKA_KEY": "polka"},
+			expected: "DB_URL is required",
+		},
+		{
+			name:     "bad duration",
+			args:     []string{"-read-timeout", "soon"},
+			env:      validEnv(),
+			expected: "-read-timeout",
+		},
+		{
+			name:     "bad platform",
+			args:     []string{"-platform", "staging"},
+			env:      validEnv(),
+			expected: "PLATFORM must be dev or prod",
+		},
+		{
+			name:     "negative edit window",
+			args:     []string{"-chirp-edit-window", "-1m"},
+			env:      validEnv(),
+			expected: "CHIRP_EDIT_WINDOW must not be negative",
+		},
+		{
+			name:     "zero trending window",
+			args:     []string{"-trending-window", "0s"},
+			env:      validEnv(),
+			expected: "TRENDING_WINDOW must be positive",
+		},
+		{
+			name:     "negative moderation reload interval",
+			args:     []string{"-moderation-reload-interval", "-1s"},
+			env:      validEnv(),
+			expected: "MODERATION_RELOAD_INTERVAL must not be negative",
+		},
+		{
+			name:     "missing file",
+			args:     []string{"-config", "does-not-exist.yaml"},
+			env:      validEnv(),
+			expected: "failed to read config file",
+		},
+	}
+	for _, c := range cases {
+		_, err := resolve(c.args, c.env)
+		if err == nil || !strings.Contains(err.Error(), c.expected) {
+			t.Errorf("%s: expected an error containing %q, received %v", c.name, c.expected, err)
+		}
+	}
+}
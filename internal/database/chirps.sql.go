@@ -7,17 +7,19 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createChirp = `-- name: CreateChirp :one
 INSERT INTO chirps
-(id, created_at, updated_at, body, user_id)
+(id, created_at, updated_at, body, user_id, parent_id, quote_of_id)
 VALUES
-($1, $2, $3, $4, $5)
-RETURNING id, created_at, updated_at, body, user_id
+($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, created_at, updated_at, body, user_id, parent_id, deleted_at, rechirp_of_id, quote_of_id, search_vector
 `
 
 type CreateChirpParams struct {
@@ -26,6 +28,8 @@ type CreateChirpParams struct {
 	UpdatedAt time.Time
 	Body      string
 	UserID    uuid.UUID
+	ParentID  uuid.NullUUID
+	QuoteOfID uuid.NullUUID
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
@@ -35,6 +39,8 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		arg.UpdatedAt,
 		arg.Body,
 		arg.UserID,
+		arg.ParentID,
+		arg.QuoteOfID,
 	)
 	var i Chirp
 	err := row.Scan(
@@ -43,6 +49,53 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.ParentID,
+		&i.DeletedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.SearchVector,
+	)
+	return i, err
+}
+
+const createRechirp = `-- name: CreateRechirp :one
+INSERT INTO chirps
+(id, created_at, updated_at, body, user_id, rechirp_of_id)
+VALUES
+($1, $2, $2, '', $3, $4::uuid)
+ON CONFLICT (user_id, rechirp_of_id) WHERE rechirp_of_id IS NOT NULL
+DO UPDATE SET updated_at = chirps.updated_at
+RETURNING id, created_at, updated_at, body, user_id, parent_id, deleted_at, rechirp_of_id, quote_of_id, search_vector
+`
+
+type CreateRechirpParams struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+}
+
+// CreateRechirp returns the existing rechirp when the user already rechirped
+// the chirp, so there is at most one per user and chirp.
+func (q *Queries) CreateRechirp(ctx context.Context, arg CreateRechirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, createRechirp,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UserID,
+		arg.ChirpID,
+	)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.ParentID,
+		&i.DeletedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.SearchVector,
 	)
 	return i, err
 }
@@ -57,10 +110,27 @@ func (q *Queries) DeleteChirpByID(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const deleteRechirp = `-- name: DeleteRechirp :exec
+DELETE FROM chirps
+WHERE user_id = $1
+  AND rechirp_of_id = $2::uuid
+`
+
+type DeleteRechirpParams struct {
+	UserID  uuid.UUID
+	ChirpID uuid.UUID
+}
+
+func (q *Queries) DeleteRechirp(ctx context.Context, arg DeleteRechirpParams) error {
+	_, err := q.db.ExecContext(ctx, deleteRechirp, arg.UserID, arg.ChirpID)
+	return err
+}
+
 const getChirpByID = `-- name: GetChirpByID :one
-SELECT id, created_at, updated_at, body, user_id
+SELECT id, created_at, updated_at, body, user_id, parent_id, deleted_at, rechirp_of_id, quote_of_id, search_vector
 FROM chirps
 WHERE id = $1
+  AND deleted_at IS NULL
 `
 
 func (q *Queries) GetChirpByID(ctx context.Context, id uuid.UUID) (Chirp, error) {
@@ -72,18 +142,218 @@ func (q *Queries) GetChirpByID(ctx context.Context, id uuid.UUID) (Chirp, error)
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.ParentID,
+		&i.DeletedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.SearchVector,
 	)
 	return i, err
 }
 
+const getChirpDescendants = `-- name: GetChirpDescendants :many
+WITH RECURSIVE descendants AS (
+    SELECT chirps.id
+    FROM chirps
+    WHERE chirps.parent_id = $1
+    UNION ALL
+    SELECT chirps.id
+    FROM chirps
+    JOIN descendants ON chirps.parent_id = descendants.id
+)
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.parent_id, chirps.deleted_at, chirps.rechirp_of_id, chirps.quote_of_id, chirps.search_vector
+FROM chirps
+JOIN descendants ON descendants.id = chirps.id
+WHERE ($2::timestamp IS NULL
+       OR (chirps.created_at, chirps.id) > ($2::timestamp, $3::uuid))
+ORDER BY chirps.created_at ASC, chirps.id ASC
+LIMIT $4
+`
+
+type GetChirpDescendantsParams struct {
+	ChirpID        uuid.UUID
+	AfterCreatedAt sql.NullTime
+	AfterID        uuid.NullUUID
+	PageLimit      int32
+}
+
+// GetChirpDescendants returns every reply under a chirp, oldest first, so a
+// reply always comes after the chirp it replies to.
+func (q *Queries) GetChirpDescendants(ctx context.Context, arg GetChirpDescendantsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpDescendants,
+		arg.ChirpID,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.ParentID,
+			&i.DeletedAt,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.SearchVector,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpPath = `-- name: GetChirpPath :many
+WITH RECURSIVE path AS (
+    SELECT chirps.id, chirps.parent_id
+    FROM chirps
+    WHERE chirps.id = $1
+    UNION ALL
+    SELECT chirps.id, chirps.parent_id
+    FROM chirps
+    JOIN path ON chirps.id = path.parent_id
+)
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.parent_id, chirps.deleted_at, chirps.rechirp_of_id, chirps.quote_of_id, chirps.search_vector
+FROM chirps
+JOIN path ON path.id = chirps.id
+ORDER BY chirps.created_at ASC, chirps.id ASC
+`
+
+// GetChirpPath returns a chirp and the chirps it replies to, up to the root
+// of the thread, root first. Deleted chirps are included as tombstones.
+func (q *Queries) GetChirpPath(ctx context.Context, id uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpPath, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.ParentID,
+			&i.DeletedAt,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.SearchVector,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getChirps = `-- name: GetChirps :many
-SELECT id, created_at, updated_at, body, user_id
+SELECT id, created_at, updated_at, body, user_id, parent_id, deleted_at, rechirp_of_id, quote_of_id, search_vector
+FROM chirps
+WHERE deleted_at IS NULL
+  AND ($1::uuid IS NULL OR user_id = $1::uuid)
+  AND ($2::timestamp IS NULL
+       OR (created_at, id) > ($2::timestamp, $3::uuid))
+  AND NOT EXISTS (
+    SELECT 1
+    FROM users
+    WHERE users.id = chirps.user_id
+      AND users.chirps_hidden
+      AND (users.suspended_until IS NULL OR users.suspended_until > NOW())
+  )
+  AND ($1::uuid IS NOT NULL OR NOT EXISTS (
+    SELECT 1
+    FROM mutes
+    WHERE mutes.muter_id = $4::uuid
+      AND mutes.muted_id = chirps.user_id
+  ))
+ORDER BY created_at ASC, id ASC
+LIMIT $5
+`
+
+type GetChirpsParams struct {
+	AuthorID       uuid.NullUUID
+	AfterCreatedAt sql.NullTime
+	AfterID        uuid.NullUUID
+	ViewerID       uuid.NullUUID
+	PageLimit      int32
+}
+
+// GetChirps leaves out the chirps of the users suspended with their chirps
+// hidden and, unless they are asked for with author_id, the chirps of the
+// users viewer_id muted.
+func (q *Queries) GetChirps(ctx context.Context, arg GetChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirps,
+		arg.AuthorID,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.ViewerID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.ParentID,
+			&i.DeletedAt,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.SearchVector,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsByIDs = `-- name: GetChirpsByIDs :many
+SELECT id, created_at, updated_at, body, user_id, parent_id, deleted_at, rechirp_of_id, quote_of_id, search_vector
 FROM chirps
-ORDER BY created_at ASC
+WHERE id = ANY($1::uuid[])
 `
 
-func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirps)
+// GetChirpsByIDs includes deleted chirps, to show them as tombstones.
+func (q *Queries) GetChirpsByIDs(ctx context.Context, ids []uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByIDs, pq.Array(ids))
 	if err != nil {
 		return nil, err
 	}
@@ -97,6 +367,11 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.ParentID,
+			&i.DeletedAt,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.SearchVector,
 		); err != nil {
 			return nil, err
 		}
@@ -110,3 +385,411 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 	}
 	return items, nil
 }
+
+const getChirpsDesc = `-- name: GetChirpsDesc :many
+SELECT id, created_at, updated_at, body, user_id, parent_id, deleted_at, rechirp_of_id, quote_of_id, search_vector
+FROM chirps
+WHERE deleted_at IS NULL
+  AND ($1::uuid IS NULL OR user_id = $1::uuid)
+  AND ($2::timestamp IS NULL
+       OR (created_at, id) < ($2::timestamp, $3::uuid))
+  AND NOT EXISTS (
+    SELECT 1
+    FROM users
+    WHERE users.id = chirps.user_id
+      AND users.chirps_hidden
+      AND (users.suspended_until IS NULL OR users.suspended_until > NOW())
+  )
+  AND ($1::uuid IS NOT NULL OR NOT EXISTS (
+    SELECT 1
+    FROM mutes
+    WHERE mutes.muter_id = $4::uuid
+      AND mutes.muted_id = chirps.user_id
+  ))
+ORDER BY created_at DESC, id DESC
+LIMIT $5
+`
+
+type GetChirpsDescParams struct {
+	AuthorID        uuid.NullUUID
+	BeforeCreatedAt sql.NullTime
+	BeforeID        uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	PageLimit       int32
+}
+
+// GetChirpsDesc is GetChirps, newest first.
+func (q *Queries) GetChirpsDesc(ctx context.Context, arg GetChirpsDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsDesc,
+		arg.AuthorID,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.ViewerID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.ParentID,
+			&i.DeletedAt,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.SearchVector,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getReplyCounts = `-- name: GetReplyCounts :many
+SELECT parent_id::uuid AS chirp_id, COUNT(*) AS reply_count
+FROM chirps
+WHERE parent_id = ANY($1::uuid[])
+  AND deleted_at IS NULL
+GROUP BY parent_id
+`
+
+type GetReplyCountsRow struct {
+	ChirpID    uuid.UUID
+	ReplyCount int64
+}
+
+func (q *Queries) GetReplyCounts(ctx context.Context, chirpIds []uuid.UUID) ([]GetReplyCountsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getReplyCounts, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetReplyCountsRow
+	for rows.Next() {
+		var i GetReplyCountsRow
+		if err := rows.Scan(&i.ChirpID, &i.ReplyCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRepostCounts = `-- name: GetRepostCounts :many
+SELECT COALESCE(rechirp_of_id, quote_of_id)::uuid AS chirp_id,
+       COUNT(rechirp_of_id) AS rechirp_count,
+       COUNT(quote_of_id) AS quote_count
+FROM chirps
+WHERE (rechirp_of_id = ANY($1::uuid[])
+       OR quote_of_id = ANY($1::uuid[]))
+  AND deleted_at IS NULL
+GROUP BY COALESCE(rechirp_of_id, quote_of_id)
+`
+
+type GetRepostCountsRow struct {
+	ChirpID      uuid.UUID
+	RechirpCount int64
+	QuoteCount   int64
+}
+
+func (q *Queries) GetRepostCounts(ctx context.Context, chirpIds []uuid.UUID) ([]GetRepostCountsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getRepostCounts, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRepostCountsRow
+	for rows.Next() {
+		var i GetRepostCountsRow
+		if err := rows.Scan(&i.ChirpID, &i.RechirpCount, &i.QuoteCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTimeline = `-- name: GetTimeline :many
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.parent_id, chirps.deleted_at, chirps.rechirp_of_id, chirps.quote_of_id, chirps.search_vector
+FROM chirps
+JOIN follows ON follows.followee_id = chirps.user_id
+WHERE follows.follower_id = $1
+  AND chirps.deleted_at IS NULL
+  AND NOT EXISTS (
+    SELECT 1
+    FROM users
+    WHERE users.id = chirps.user_id
+      AND users.chirps_hidden
+      AND (users.suspended_until IS NULL OR users.suspended_until > NOW())
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM mutes
+    WHERE mutes.muter_id = $1
+      AND mutes.muted_id = chirps.user_id
+  )
+  AND ($2::timestamp IS NULL
+       OR (chirps.created_at, chirps.id) < ($2::timestamp, $3::uuid))
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT $4
+`
+
+type GetTimelineParams struct {
+	UserID          uuid.UUID
+	BeforeCreatedAt sql.NullTime
+	BeforeID        uuid.NullUUID
+	PageLimit       int32
+}
+
+// GetTimeline leaves out the chirps of the followed users user_id muted.
+func (q *Queries) GetTimeline(ctx context.Context, arg GetTimelineParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getTimeline,
+		arg.UserID,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.ParentID,
+			&i.DeletedAt,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.SearchVector,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const hasReplies = `-- name: HasReplies :one
+SELECT EXISTS (
+    SELECT 1
+    FROM chirps
+    WHERE parent_id = $1::uuid
+)
+`
+
+// HasReplies counts deleted replies too, unlike GetReplyCounts: a tombstone
+// still holds its own replies in the thread.
+func (q *Queries) HasReplies(ctx context.Context, chirpID uuid.UUID) (bool, error) {
+	row := q.db.QueryRowContext(ctx, hasReplies, chirpID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const searchChirps = `-- name: SearchChirps :many
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.parent_id, chirps.deleted_at, chirps.rechirp_of_id, chirps.quote_of_id, chirps.search_vector,
+       ts_rank(chirps.search_vector, search) AS rank,
+       ts_headline('english', chirps.body, search,
+                   'HighlightAll=true, StartSel=' || chr(2) || ', StopSel=' || chr(3)) AS headline
+FROM chirps, websearch_to_tsquery('english', $1) AS search
+WHERE chirps.search_vector @@ search
+  AND chirps.deleted_at IS NULL
+  AND NOT EXISTS (
+    SELECT 1
+    FROM users
+    WHERE users.id = chirps.user_id
+      AND users.chirps_hidden
+      AND (users.suspended_until IS NULL OR users.suspended_until > NOW())
+  )
+  AND ($2::real IS NULL
+       OR (ts_rank(chirps.search_vector, search), chirps.id) < ($2::real, $3::uuid))
+  AND NOT EXISTS (
+    SELECT 1
+    FROM mutes
+    WHERE mutes.muter_id = $4::uuid
+      AND mutes.muted_id = chirps.user_id
+  )
+ORDER BY rank DESC, chirps.id DESC
+LIMIT $5
+`
+
+type SearchChirpsParams struct {
+	Query      string
+	BeforeRank sql.NullFloat64
+	BeforeID   uuid.NullUUID
+	ViewerID   uuid.NullUUID
+	PageLimit  int32
+}
+
+type SearchChirpsRow struct {
+	Chirp    Chirp
+	Rank     float32
+	Headline string
+}
+
+// SearchChirps ranks the chirps matching a web search style query, best
+// first, leaving out the chirps of the users viewer_id muted. Headline is the
+// whole body with every match between a \x02 and a \x03, markers a chirp is
+// unlikely to contain.
+func (q *Queries) SearchChirps(ctx context.Context, arg SearchChirpsParams) ([]SearchChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchChirps,
+		arg.Query,
+		arg.BeforeRank,
+		arg.BeforeID,
+		arg.ViewerID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchChirpsRow
+	for rows.Next() {
+		var i SearchChirpsRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.ParentID,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.RechirpOfID,
+			&i.Chirp.QuoteOfID,
+			&i.Chirp.SearchVector,
+			&i.Rank,
+			&i.Headline,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const tombstoneChirp = `-- name: TombstoneChirp :exec
+WITH revisions AS (
+    DELETE FROM chirp_revisions
+    WHERE chirp_id = $1
+), likes AS (
+    DELETE FROM chirp_likes
+    WHERE chirp_id = $1
+), hashtags AS (
+    DELETE FROM chirp_hashtags
+    WHERE chirp_id = $1
+), mentions AS (
+    DELETE FROM mentions
+    WHERE chirp_id = $1
+), flags AS (
+    DELETE FROM chirp_flags
+    WHERE chirp_id = $1
+), rechirps AS (
+    DELETE FROM chirps
+    WHERE rechirp_of_id = $1
+)
+UPDATE chirps
+SET body = '', deleted_at = $2, updated_at = $2
+WHERE id = $1
+`
+
+type TombstoneChirpParams struct {
+	ID        uuid.UUID
+	DeletedAt sql.NullTime
+}
+
+// TombstoneChirp blanks a chirp instead of deleting it, so the replies keep
+// their place in the thread. Its revisions, likes, hashtags, mentions, flags
+// and plain rechirps go, like on a real delete.
+func (q *Queries) TombstoneChirp(ctx context.Context, arg TombstoneChirpParams) error {
+	_, err := q.db.ExecContext(ctx, tombstoneChirp, arg.ID, arg.DeletedAt)
+	return err
+}
+
+const updateChirp = `-- name: UpdateChirp :one
+WITH previous AS (
+    INSERT INTO chirp_revisions (id, chirp_id, body, replaced_at)
+    SELECT $1, id, body, $2
+    FROM chirps
+    WHERE id = $3
+)
+UPDATE chirps
+SET body = $4, updated_at = $2
+WHERE id = $3
+RETURNING id, created_at, updated_at, body, user_id, parent_id, deleted_at, rechirp_of_id, quote_of_id, search_vector
+`
+
+type UpdateChirpParams struct {
+	RevisionID uuid.UUID
+	UpdatedAt  time.Time
+	ID         uuid.UUID
+	Body       string
+}
+
+// UpdateChirp replaces the body of a chirp and keeps the previous one as a
+// revision, in a single statement so an edit is never half applied.
+func (q *Queries) UpdateChirp(ctx context.Context, arg UpdateChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirp,
+		arg.RevisionID,
+		arg.UpdatedAt,
+		arg.ID,
+		arg.Body,
+	)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.ParentID,
+		&i.DeletedAt,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.SearchVector,
+	)
+	return i, err
+}
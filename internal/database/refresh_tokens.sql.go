@@ -15,10 +15,10 @@ import (
 
 const createRefreshToken = `-- name: CreateRefreshToken :one
 INSERT INTO refresh_tokens
-(token, created_at, updated_at, expires_at, revoked_at, user_id)
+(token, created_at, updated_at, expires_at, revoked_at, user_id, family_id)
 VALUES
-($1, $2, $3, $4, $5, $6)
-RETURNING token, created_at, updated_at, expires_at, revoked_at, user_id
+($1, $2, $3, $4, $5, $6, $7)
+RETURNING token, created_at, updated_at, expires_at, revoked_at, user_id, family_id, rotated_at
 `
 
 type CreateRefreshTokenParams struct {
@@ -28,6 +28,7 @@ type CreateRefreshTokenParams struct {
 	ExpiresAt time.Time
 	RevokedAt sql.NullTime
 	UserID    uuid.UUID
+	FamilyID  uuid.UUID
 }
 
 func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (RefreshToken, error) {
@@ -38,6 +39,7 @@ func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshToken
 		arg.ExpiresAt,
 		arg.RevokedAt,
 		arg.UserID,
+		arg.FamilyID,
 	)
 	var i RefreshToken
 	err := row.Scan(
@@ -47,12 +49,14 @@ func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshToken
 		&i.ExpiresAt,
 		&i.RevokedAt,
 		&i.UserID,
+		&i.FamilyID,
+		&i.RotatedAt,
 	)
 	return i, err
 }
 
 const getRefreshToken = `-- name: GetRefreshToken :one
-SELECT token, created_at, updated_at, expires_at, revoked_at, user_id
+SELECT token, created_at, updated_at, expires_at, revoked_at, user_id, family_id, rotated_at
 FROM refresh_tokens
 WHERE token = $1
 `
@@ -67,18 +71,64 @@ func (q *Queries) GetRefreshToken(ctx context.Context, token string) (RefreshTok
 		&i.ExpiresAt,
 		&i.RevokedAt,
 		&i.UserID,
+		&i.FamilyID,
+		&i.RotatedAt,
 	)
 	return i, err
 }
 
-const getRefreshTokenByUserId = `-- name: GetRefreshTokenByUserId :one
-SELECT token, created_at, updated_at, expires_at, revoked_at, user_id
-FROM refresh_tokens
+const revokeRefreshToken = `-- name: RevokeRefreshToken :exec
+UPDATE refresh_tokens
+SET updated_at = NOW(),
+    revoked_at = NOW()
+WHERE token = $1
+`
+
+func (q *Queries) RevokeRefreshToken(ctx context.Context, token string) error {
+	_, err := q.db.ExecContext(ctx, revokeRefreshToken, token)
+	return err
+}
+
+const revokeRefreshTokenFamily = `-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_tokens
+SET updated_at = NOW(),
+    revoked_at = NOW()
+WHERE family_id = $1
+  AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, revokeRefreshTokenFamily, familyID)
+	return err
+}
+
+const revokeUserRefreshTokens = `-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens
+SET updated_at = NOW(),
+    revoked_at = NOW()
 WHERE user_id = $1
+  AND revoked_at IS NULL
 `
 
-func (q *Queries) GetRefreshTokenByUserId(ctx context.Context, userID uuid.UUID) (RefreshToken, error) {
-	row := q.db.QueryRowContext(ctx, getRefreshTokenByUserId, userID)
+func (q *Queries) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, revokeUserRefreshTokens, userID)
+	return err
+}
+
+const rotateRefreshToken = `-- name: RotateRefreshToken :one
+UPDATE refresh_tokens
+SET updated_at = NOW(),
+    rotated_at = NOW()
+WHERE token = $1
+  AND revoked_at IS NULL
+  AND rotated_at IS NULL
+RETURNING token, created_at, updated_at, expires_at, revoked_at, user_id, family_id, rotated_at
+`
+
+// Retires a token that is still active. No row is returned when the token
+// has already been rotated or revoked, which means it is being reused.
+func (q *Queries) RotateRefreshToken(ctx context.Context, token string) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, rotateRefreshToken, token)
 	var i RefreshToken
 	err := row.Scan(
 		&i.Token,
@@ -87,18 +137,8 @@ func (q *Queries) GetRefreshTokenByUserId(ctx context.Context, userID uuid.UUID)
 		&i.ExpiresAt,
 		&i.RevokedAt,
 		&i.UserID,
+		&i.FamilyID,
+		&i.RotatedAt,
 	)
 	return i, err
 }
-
-const revokeRefreshToken = `-- name: RevokeRefreshToken :exec
-UPDATE refresh_tokens
-SET updated_at = NOW(),
-    revoked_at = NOW()
-WHERE token = $1
-`
-
-func (q *Queries) RevokeRefreshToken(ctx context.Context, token string) error {
-	_, err := q.db.ExecContext(ctx, revokeRefreshToken, token)
-	return err
-}
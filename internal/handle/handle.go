@@ -0,0 +1,98 @@
+// Package handle validates usernames and finds the @mentions of chirp
+// bodies.
+package handle
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	MinLength = 3
+	MaxLength = 20
+)
+
+// reserved holds names that could pass for the service or its staff, or
+// that clash with routes and mention conventions.
+var reserved = map[string]bool{
+	"admin":         true,
+	"administrator": true,
+	"anonymous":     true,
+	"api":           true,
+	"app":           true,
+	"chirpy":        true,
+	"everyone":      true,
+	"help":          true,
+	"here":          true,
+	"me":            true,
+	"metrics":       true,
+	"mod":           true,
+	"moderator":     true,
+	"null":          true,
+	"root":          true,
+	"staff":         true,
+	"support":       true,
+	"system":        true,
+	"undefined":     true,
+}
+
+// Validate returns why username cannot be used, or nil. Usernames are
+// compared case-insensitively, so "Bob" and "bob" are the same user.
+func Validate(username string) error {
+	if n := len(username); n < MinLength || n > MaxLength {
+		return fmt.Errorf("username must be between %d and %d characters", MinLength, MaxLength)
+	}
+	for i := 0; i < len(username); i++ {
+		if !isHandleByte(username[i]) {
+			return errors.New("username may only contain letters, digits and underscores")
+		}
+	}
+	if reserved[strings.ToLower(username)] {
+		return errors.New("username is reserved")
+	}
+	return nil
+}
+
+// Extract returns the handles mentioned in text, lower case and without
+// duplicates, in the order they first appear. Whether they belong to a user
+// is up to the caller.
+//
+// A mention is an '@' followed by a handle. The '@' must not follow a handle
+// character or another '@', so email addresses mention nobody. A handle
+// longer than MaxLength, or running into a letter handles cannot hold, is
+// not a mention rather than a shorter one.
+func Extract(text string) []string {
+	var handles []string
+	seen := map[string]bool{}
+	for i := 0; i < len(text); i++ {
+		if text[i] != '@' {
+			continue
+		}
+		if i > 0 && (isHandleByte(text[i-1]) || text[i-1] == '@') {
+			continue
+		}
+		end := i + 1
+		for end < len(text) && isHandleByte(text[end]) {
+			end++
+		}
+		h := strings.ToLower(text[i+1 : end])
+		next, _ := utf8.DecodeRuneInString(text[end:])
+		if unicode.IsLetter(next) || unicode.IsMark(next) || unicode.IsDigit(next) {
+			i = end - 1
+			continue
+		}
+		if n := len(h); n >= MinLength && n <= MaxLength && !seen[h] {
+			seen[h] = true
+			handles = append(handles, h)
+		}
+		i = end - 1
+	}
+	return handles
+}
+
+func isHandleByte(b byte) bool {
+	return 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || '0' <= b && b <= '9' || b == '_'
+}
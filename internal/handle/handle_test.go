@@ -0,0 +1,55 @@
+package handle
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected string
+	}{
+		{input: "bob", expected: ""},
+		{input: "Alice_1990", expected: ""},
+		{input: "ab", expected: "between 3 and 20"},
+		{input: strings.Repeat("a", MaxLength+1), expected: "between 3 and 20"},
+		{input: "bob.smith", expected: "letters, digits and underscores"},
+		{input: "zoë_x", expected: "letters, digits and underscores"},
+		{input: "Admin", expected: "reserved"},
+		{input: "ME_", expected: ""},
+	}
+
+	for _, c := range cases {
+		err := Validate(c.input)
+		if c.expected == "" && err != nil {
+			t.Errorf("Validate(%q): unexpected error: %v", c.input, err)
+		}
+		if c.expected != "" && (err == nil || !strings.Contains(err.Error(), c.expected)) {
+			t.Errorf("Validate(%q): expected an error containing %q, received %v", c.input, c.expected, err)
+		}
+	}
+}
+
+func TestExtract(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected []string
+	}{
+		{input: "no mentions", expected: nil},
+		{input: "hi @Bob and @bob, @alice!", expected: []string{"bob", "alice"}},
+		{input: "(@first),@second;", expected: []string{"first", "second"}},
+		{input: "mail bob@example.com or @@double", expected: nil},
+		{input: "@ab is too short", expected: nil},
+		{input: "@" + strings.Repeat("a", MaxLength+1), expected: nil},
+		{input: "@café is not @caf", expected: []string{"caf"}},
+		{input: "thanks @bob— and @alice🎉", expected: []string{"bob", "alice"}},
+	}
+
+	for _, c := range cases {
+		if actual := Extract(c.input); !slices.Equal(actual, c.expected) {
+			t.Errorf("Extract(%q): expected %q, received %q", c.input, c.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Package hashtag finds the hashtags of chirp bodies.
+package hashtag
+
+import (
+	"strings"
+	"unicode"
+)
+
+// MaxLength is the longest tag kept, in runes. Longer tags are ignored
+// rather than cut, a truncated tag would be another tag.
+const MaxLength = 100
+
+// Extract returns the tags of text, normalized and without duplicates, in
+// the order they first appear.
+//
+// A tag is a '#' followed by letters, marks, digits and underscores, with at
+// least one letter. The '#' must not follow one of those characters, another
+// '#' or a '&', so "C#", "a#b", "##x" and "&#39;" hold no tag. The tag ends
+// at the first other character, punctuation included.
+func Extract(text string) []string {
+	var tags []string
+	seen := map[string]bool{}
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '#' {
+			continue
+		}
+		if i > 0 && (isTagRune(runes[i-1]) || runes[i-1] == '#' || runes[i-1] == '&') {
+			continue
+		}
+		end := i + 1
+		for end < len(runes) && isTagRune(runes[end]) {
+			end++
+		}
+		if tag, ok := Normalize(string(runes[i+1 : end])); ok && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+		i = end - 1
+	}
+	return tags
+}
+
+// Normalize returns the stored form of a tag written without its '#'. ok is
+// false when tag is not a valid tag.
+func Normalize(tag string) (normalized string, ok bool) {
+	hasLetter := false
+	length := 0
+	for _, r := range tag {
+		if !isTagRune(r) {
+			return "", false
+		}
+		if unicode.IsLetter(r) {
+			hasLetter = true
+		}
+		length++
+	}
+	if !hasLetter || length > MaxLength {
+		return "", false
+	}
+	return strings.ToLower(tag), true
+}
+
+func isTagRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsMark(r) || unicode.IsDigit(r) || r == '_'
+}
@@ -0,0 +1,54 @@
+package hashtag
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected []string
+	}{
+		{input: "no tags here", expected: nil},
+		{input: "#Go is fun, #go!", expected: []string{"go"}},
+		{input: "ending a sentence with #chirpy.", expected: []string{"chirpy"}},
+		{input: "(#first),#second;#third?", expected: []string{"first", "second", "third"}},
+		{input: "#snake_case and #with2024", expected: []string{"snake_case", "with2024"}},
+		{input: "#2024 is only digits, #_ has no letter", expected: nil},
+		{input: "C# a#b ##double &#39;", expected: nil},
+		{input: "#café #Ünïcödé #日本語", expected: []string{"café", "ünïcödé", "日本語"}},
+		{input: "#हिन्दी with combining marks", expected: []string{"हिन्दी"}},
+		{input: "#one#two", expected: []string{"one"}},
+		{input: "#" + strings.Repeat("a", MaxLength+1), expected: nil},
+		{input: "#" + strings.Repeat("a", MaxLength), expected: []string{strings.Repeat("a", MaxLength)}},
+	}
+
+	for _, c := range cases {
+		if actual := Extract(c.input); !slices.Equal(actual, c.expected) {
+			t.Errorf("Extract(%q): expected %q, received %q", c.input, c.expected, actual)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected string
+		ok       bool
+	}{
+		{input: "GoLang", expected: "golang", ok: true},
+		{input: "Ünïcödé", expected: "ünïcödé", ok: true},
+		{input: "#golang", ok: false},
+		{input: "two words", ok: false},
+		{input: "", ok: false},
+	}
+
+	for _, c := range cases {
+		actual, ok := Normalize(c.input)
+		if actual != c.expected || ok != c.ok {
+			t.Errorf("Normalize(%q): expected %q, %v, received %q, %v", c.input, c.expected, c.ok, actual, ok)
+		}
+	}
+}
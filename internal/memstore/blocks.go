@@ -0,0 +1,78 @@
+package memstore
+
+import (
+	"context"
+
+	"github.com/Specialized101/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateBlock(ctx context.Context, arg database.CreateBlockParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[arg.BlockerID]; !ok {
+		return foreignKeyError("blocks", "blocker_id")
+	}
+	if _, ok := s.users[arg.BlockedID]; !ok {
+		return foreignKeyError("blocks", "blocked_id")
+	}
+	delete(s.follows, followKey{followerID: arg.BlockerID, followeeID: arg.BlockedID})
+	delete(s.follows, followKey{followerID: arg.BlockedID, followeeID: arg.BlockerID})
+	key := blockKey{userID: arg.BlockerID, targetID: arg.BlockedID}
+	if _, ok := s.blocks[key]; ok {
+		return nil
+	}
+	s.blocks[key] = database.Block(arg)
+	return nil
+}
+
+func (s *Store) DeleteBlock(ctx context.Context, arg database.DeleteBlockParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.blocks, blockKey{userID: arg.BlockerID, targetID: arg.BlockedID})
+	return nil
+}
+
+func (s *Store) IsBlocked(ctx context.Context, arg database.IsBlockedParams) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.blocked(arg.BlockerID, arg.BlockedID), nil
+}
+
+func (s *Store) blocked(blockerID, blockedID uuid.UUID) bool {
+	_, ok := s.blocks[blockKey{userID: blockerID, targetID: blockedID}]
+	return ok
+}
+
+func (s *Store) CreateMute(ctx context.Context, arg database.CreateMuteParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[arg.MuterID]; !ok {
+		return foreignKeyError("mutes", "muter_id")
+	}
+	if _, ok := s.users[arg.MutedID]; !ok {
+		return foreignKeyError("mutes", "muted_id")
+	}
+	key := blockKey{userID: arg.MuterID, targetID: arg.MutedID}
+	if _, ok := s.mutes[key]; ok {
+		return nil
+	}
+	s.mutes[key] = database.Mute(arg)
+	return nil
+}
+
+func (s *Store) DeleteMute(ctx context.Context, arg database.DeleteMuteParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.mutes, blockKey{userID: arg.MuterID, targetID: arg.MutedID})
+	return nil
+}
+
+// muted reports whether viewerID muted userID, never when there is no viewer.
+func (s *Store) muted(viewerID uuid.NullUUID, userID uuid.UUID) bool {
+	if !viewerID.Valid {
+		return false
+	}
+	_, ok := s.mutes[blockKey{userID: viewerID.UUID, targetID: userID}]
+	return ok
+}
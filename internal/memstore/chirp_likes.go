@@ -0,0 +1,110 @@
+package memstore
+
+import (
+	"context"
+	"slices"
+
+	"github.com/Specialized101/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateChirpLike(ctx context.Context, arg database.CreateChirpLikeParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[arg.UserID]; !ok {
+		return foreignKeyError("chirp_likes", "user_id")
+	}
+	if _, ok := s.chirps[arg.ChirpID]; !ok {
+		return foreignKeyError("chirp_likes", "chirp_id")
+	}
+	key := likeKey{userID: arg.UserID, chirpID: arg.ChirpID}
+	if _, ok := s.likes[key]; ok {
+		return nil
+	}
+	s.likes[key] = database.ChirpLike(arg)
+	return nil
+}
+
+func (s *Store) DeleteChirpLike(ctx context.Context, arg database.DeleteChirpLikeParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.likes, likeKey{userID: arg.UserID, chirpID: arg.ChirpID})
+	return nil
+}
+
+func (s *Store) deleteLikes(chirpID uuid.UUID) {
+	for key := range s.likes {
+		if key.chirpID == chirpID {
+			delete(s.likes, key)
+		}
+	}
+}
+
+func (s *Store) GetChirpLikes(ctx context.Context, arg database.GetChirpLikesParams) ([]database.GetChirpLikesRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var items []database.GetChirpLikesRow
+	for _, l := range s.likes {
+		if l.ChirpID != arg.ChirpID {
+			continue
+		}
+		if arg.BeforeCreatedAt.Valid &&
+			compareKeys(l.CreatedAt, l.UserID, arg.BeforeCreatedAt.Time, arg.BeforeID.UUID) >= 0 {
+			continue
+		}
+		items = append(items, database.GetChirpLikesRow{UserID: l.UserID, CreatedAt: l.CreatedAt})
+	}
+	slices.SortFunc(items, func(a, b database.GetChirpLikesRow) int {
+		return compareKeys(b.CreatedAt, b.UserID, a.CreatedAt, a.UserID)
+	})
+	return limit(items, arg.PageLimit), nil
+}
+
+func (s *Store) GetUserLikes(ctx context.Context, arg database.GetUserLikesParams) ([]database.GetUserLikesRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var items []database.GetUserLikesRow
+	for _, l := range s.likes {
+		c, ok := s.chirps[l.ChirpID]
+		if l.UserID != arg.UserID || !ok || c.DeletedAt.Valid || s.chirpsHidden(c.UserID) || s.muted(arg.ViewerID, c.UserID) {
+			continue
+		}
+		if arg.BeforeCreatedAt.Valid &&
+			compareKeys(l.CreatedAt, l.ChirpID, arg.BeforeCreatedAt.Time, arg.BeforeID.UUID) >= 0 {
+			continue
+		}
+		items = append(items, database.GetUserLikesRow{Chirp: c, LikedAt: l.CreatedAt})
+	}
+	slices.SortFunc(items, func(a, b database.GetUserLikesRow) int {
+		return compareKeys(b.LikedAt, b.Chirp.ID, a.LikedAt, a.Chirp.ID)
+	})
+	return limit(items, arg.PageLimit), nil
+}
+
+func (s *Store) GetLikeCounts(ctx context.Context, chirpIds []uuid.UUID) ([]database.GetLikeCountsRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := map[uuid.UUID]int64{}
+	for _, l := range s.likes {
+		if slices.Contains(chirpIds, l.ChirpID) {
+			counts[l.ChirpID]++
+		}
+	}
+	var items []database.GetLikeCountsRow
+	for id, n := range counts {
+		items = append(items, database.GetLikeCountsRow{ChirpID: id, LikeCount: n})
+	}
+	return items, nil
+}
+
+func (s *Store) GetLikedChirpIDs(ctx context.Context, arg database.GetLikedChirpIDsParams) ([]uuid.UUID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var items []uuid.UUID
+	for _, id := range arg.ChirpIds {
+		if _, ok := s.likes[likeKey{userID: arg.UserID, chirpID: id}]; ok {
+			items = append(items, id)
+		}
+	}
+	return items, nil
+}
@@ -0,0 +1,373 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+
+	"github.com/Specialized101/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateChirp(ctx context.Context, arg database.CreateChirpParams) (database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[arg.UserID]; !ok {
+		return database.Chirp{}, foreignKeyError("chirps", "user_id")
+	}
+	if _, ok := s.chirps[arg.ID]; ok {
+		return database.Chirp{}, uniqueError("chirps", "id")
+	}
+	if _, ok := s.chirps[arg.ParentID.UUID]; arg.ParentID.Valid && !ok {
+		return database.Chirp{}, foreignKeyError("chirps", "parent_id")
+	}
+	if _, ok := s.chirps[arg.QuoteOfID.UUID]; arg.QuoteOfID.Valid && !ok {
+		return database.Chirp{}, foreignKeyError("chirps", "quote_of_id")
+	}
+	chirp := database.Chirp{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		Body:      arg.Body,
+		UserID:    arg.UserID,
+		ParentID:  arg.ParentID,
+		QuoteOfID: arg.QuoteOfID,
+	}
+	s.chirps[chirp.ID] = chirp
+	return chirp, nil
+}
+
+func (s *Store) CreateRechirp(ctx context.Context, arg database.CreateRechirpParams) (database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[arg.UserID]; !ok {
+		return database.Chirp{}, foreignKeyError("chirps", "user_id")
+	}
+	if _, ok := s.chirps[arg.ChirpID]; !ok {
+		return database.Chirp{}, foreignKeyError("chirps", "rechirp_of_id")
+	}
+	for _, c := range s.chirps {
+		if c.UserID == arg.UserID && c.RechirpOfID.Valid && c.RechirpOfID.UUID == arg.ChirpID {
+			return c, nil
+		}
+	}
+	if _, ok := s.chirps[arg.ID]; ok {
+		return database.Chirp{}, uniqueError("chirps", "id")
+	}
+	chirp := database.Chirp{
+		ID:          arg.ID,
+		CreatedAt:   arg.CreatedAt,
+		UpdatedAt:   arg.CreatedAt,
+		UserID:      arg.UserID,
+		RechirpOfID: uuid.NullUUID{UUID: arg.ChirpID, Valid: true},
+	}
+	s.chirps[chirp.ID] = chirp
+	return chirp, nil
+}
+
+func (s *Store) DeleteRechirp(ctx context.Context, arg database.DeleteRechirpParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.chirps {
+		if c.UserID == arg.UserID && c.RechirpOfID.Valid && c.RechirpOfID.UUID == arg.ChirpID {
+			delete(s.chirps, c.ID)
+		}
+	}
+	return nil
+}
+
+func (s *Store) DeleteChirpByID(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.chirps, id)
+	s.deleteRevisions(id)
+	s.deleteLikes(id)
+	s.deleteHashtags(id)
+	s.deleteMentions(id)
+	delete(s.chirpFlags, id)
+	s.deleteRechirps(id)
+	for _, r := range s.reports {
+		if r.ChirpID.Valid && r.ChirpID.UUID == id {
+			r.ChirpID = uuid.NullUUID{}
+			s.reports[r.ID] = r
+		}
+	}
+	for _, c := range s.chirps {
+		if c.ParentID.Valid && c.ParentID.UUID == id {
+			c.ParentID = uuid.NullUUID{}
+		}
+		if c.QuoteOfID.Valid && c.QuoteOfID.UUID == id {
+			c.QuoteOfID = uuid.NullUUID{}
+		}
+		s.chirps[c.ID] = c
+	}
+	return nil
+}
+
+func (s *Store) TombstoneChirp(ctx context.Context, arg database.TombstoneChirpParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.chirps[arg.ID]
+	if !ok {
+		return nil
+	}
+	s.deleteRevisions(arg.ID)
+	s.deleteLikes(arg.ID)
+	s.deleteHashtags(arg.ID)
+	s.deleteMentions(arg.ID)
+	delete(s.chirpFlags, arg.ID)
+	s.deleteRechirps(arg.ID)
+	c.Body = ""
+	c.DeletedAt = arg.DeletedAt
+	c.UpdatedAt = arg.DeletedAt.Time
+	s.chirps[c.ID] = c
+	return nil
+}
+
+func (s *Store) deleteRevisions(chirpID uuid.UUID) {
+	for id, r := range s.revisions {
+		if r.ChirpID == chirpID {
+			delete(s.revisions, id)
+		}
+	}
+}
+
+func (s *Store) deleteRechirps(chirpID uuid.UUID) {
+	for id, c := range s.chirps {
+		if c.RechirpOfID.Valid && c.RechirpOfID.UUID == chirpID {
+			delete(s.chirps, id)
+		}
+	}
+}
+
+func (s *Store) GetChirpByID(ctx context.Context, id uuid.UUID) (database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.chirps[id]
+	if !ok || c.DeletedAt.Valid {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+	return c, nil
+}
+
+func (s *Store) GetChirpPath(ctx context.Context, id uuid.UUID) ([]database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var items []database.Chirp
+	for c, ok := s.chirps[id]; ok; {
+		items = append(items, c)
+		if !c.ParentID.Valid {
+			break
+		}
+		c, ok = s.chirps[c.ParentID.UUID]
+	}
+	slices.SortFunc(items, compareChirps)
+	return items, nil
+}
+
+func (s *Store) GetChirpDescendants(ctx context.Context, arg database.GetChirpDescendantsParams) ([]database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	descendants := map[uuid.UUID]bool{arg.ChirpID: true}
+	// Replies are created after their parent, so walking the chirps in order
+	// sees every parent before its replies.
+	all := s.filterChirps(func(database.Chirp) bool { return true })
+	slices.SortFunc(all, compareChirps)
+	var items []database.Chirp
+	for _, c := range all {
+		if !c.ParentID.Valid || !descendants[c.ParentID.UUID] {
+			continue
+		}
+		descendants[c.ID] = true
+		if !arg.AfterCreatedAt.Valid ||
+			compareKeys(c.CreatedAt, c.ID, arg.AfterCreatedAt.Time, arg.AfterID.UUID) > 0 {
+			items = append(items, c)
+		}
+	}
+	return limit(items, arg.PageLimit), nil
+}
+
+func (s *Store) GetReplyCounts(ctx context.Context, chirpIds []uuid.UUID) ([]database.GetReplyCountsRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := map[uuid.UUID]int64{}
+	for _, c := range s.chirps {
+		if c.ParentID.Valid && !c.DeletedAt.Valid && slices.Contains(chirpIds, c.ParentID.UUID) {
+			counts[c.ParentID.UUID]++
+		}
+	}
+	var items []database.GetReplyCountsRow
+	for id, n := range counts {
+		items = append(items, database.GetReplyCountsRow{ChirpID: id, ReplyCount: n})
+	}
+	return items, nil
+}
+
+func (s *Store) HasReplies(ctx context.Context, chirpID uuid.UUID) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.chirps {
+		if c.ParentID.Valid && c.ParentID.UUID == chirpID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *Store) GetRepostCounts(ctx context.Context, chirpIds []uuid.UUID) ([]database.GetRepostCountsRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := map[uuid.UUID]*database.GetRepostCountsRow{}
+	count := func(id uuid.UUID) *database.GetRepostCountsRow {
+		if counts[id] == nil {
+			counts[id] = &database.GetRepostCountsRow{ChirpID: id}
+		}
+		return counts[id]
+	}
+	for _, c := range s.chirps {
+		if c.DeletedAt.Valid {
+			continue
+		}
+		if c.RechirpOfID.Valid && slices.Contains(chirpIds, c.RechirpOfID.UUID) {
+			count(c.RechirpOfID.UUID).RechirpCount++
+		}
+		if c.QuoteOfID.Valid && slices.Contains(chirpIds, c.QuoteOfID.UUID) {
+			count(c.QuoteOfID.UUID).QuoteCount++
+		}
+	}
+	var items []database.GetRepostCountsRow
+	for _, c := range counts {
+		items = append(items, *c)
+	}
+	return items, nil
+}
+
+func (s *Store) GetChirpsByIDs(ctx context.Context, ids []uuid.UUID) ([]database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.filterChirps(func(c database.Chirp) bool {
+		return slices.Contains(ids, c.ID)
+	}), nil
+}
+
+func (s *Store) GetChirps(ctx context.Context, arg database.GetChirpsParams) ([]database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items := s.filterChirps(func(c database.Chirp) bool {
+		if c.DeletedAt.Valid {
+			return false
+		}
+		if arg.AuthorID.Valid && c.UserID != arg.AuthorID.UUID {
+			return false
+		}
+		if s.chirpsHidden(c.UserID) {
+			return false
+		}
+		if !arg.AuthorID.Valid && s.muted(arg.ViewerID, c.UserID) {
+			return false
+		}
+		return !arg.AfterCreatedAt.Valid ||
+			compareKeys(c.CreatedAt, c.ID, arg.AfterCreatedAt.Time, arg.AfterID.UUID) > 0
+	})
+	slices.SortFunc(items, compareChirps)
+	return limit(items, arg.PageLimit), nil
+}
+
+func (s *Store) GetChirpsDesc(ctx context.Context, arg database.GetChirpsDescParams) ([]database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items := s.filterChirps(func(c database.Chirp) bool {
+		if c.DeletedAt.Valid {
+			return false
+		}
+		if arg.AuthorID.Valid && c.UserID != arg.AuthorID.UUID {
+			return false
+		}
+		if s.chirpsHidden(c.UserID) {
+			return false
+		}
+		if !arg.AuthorID.Valid && s.muted(arg.ViewerID, c.UserID) {
+			return false
+		}
+		return !arg.BeforeCreatedAt.Valid ||
+			compareKeys(c.CreatedAt, c.ID, arg.BeforeCreatedAt.Time, arg.BeforeID.UUID) < 0
+	})
+	slices.SortFunc(items, func(a, b database.Chirp) int { return compareChirps(b, a) })
+	return limit(items, arg.PageLimit), nil
+}
+
+func (s *Store) GetTimeline(ctx context.Context, arg database.GetTimelineParams) ([]database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items := s.filterChirps(func(c database.Chirp) bool {
+		if _, ok := s.follows[followKey{followerID: arg.UserID, followeeID: c.UserID}]; !ok || c.DeletedAt.Valid {
+			return false
+		}
+		if s.chirpsHidden(c.UserID) {
+			return false
+		}
+		if s.muted(uuid.NullUUID{UUID: arg.UserID, Valid: true}, c.UserID) {
+			return false
+		}
+		return !arg.BeforeCreatedAt.Valid ||
+			compareKeys(c.CreatedAt, c.ID, arg.BeforeCreatedAt.Time, arg.BeforeID.UUID) < 0
+	})
+	slices.SortFunc(items, func(a, b database.Chirp) int { return compareChirps(b, a) })
+	return limit(items, arg.PageLimit), nil
+}
+
+func (s *Store) UpdateChirp(ctx context.Context, arg database.UpdateChirpParams) (database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	chirp, ok := s.chirps[arg.ID]
+	if !ok {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+	if _, ok := s.revisions[arg.RevisionID]; ok {
+		return database.Chirp{}, uniqueError("chirp_revisions", "id")
+	}
+	s.revisions[arg.RevisionID] = database.ChirpRevision{
+		ID:         arg.RevisionID,
+		ChirpID:    chirp.ID,
+		Body:       chirp.Body,
+		ReplacedAt: arg.UpdatedAt,
+	}
+	chirp.Body = arg.Body
+	chirp.UpdatedAt = arg.UpdatedAt
+	s.chirps[chirp.ID] = chirp
+	return chirp, nil
+}
+
+func (s *Store) GetChirpRevisions(ctx context.Context, arg database.GetChirpRevisionsParams) ([]database.ChirpRevision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var items []database.ChirpRevision
+	for _, r := range s.revisions {
+		if r.ChirpID != arg.ChirpID {
+			continue
+		}
+		if arg.BeforeReplacedAt.Valid &&
+			compareKeys(r.ReplacedAt, r.ID, arg.BeforeReplacedAt.Time, arg.BeforeID.UUID) >= 0 {
+			continue
+		}
+		items = append(items, r)
+	}
+	slices.SortFunc(items, func(a, b database.ChirpRevision) int {
+		return compareKeys(b.ReplacedAt, b.ID, a.ReplacedAt, a.ID)
+	})
+	return limit(items, arg.PageLimit), nil
+}
+
+func (s *Store) filterChirps(keep func(database.Chirp) bool) []database.Chirp {
+	var items []database.Chirp
+	for _, c := range s.chirps {
+		if keep(c) {
+			items = append(items, c)
+		}
+	}
+	return items
+}
+
+func compareChirps(a, b database.Chirp) int {
+	return compareKeys(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+}
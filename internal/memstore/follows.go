@@ -0,0 +1,72 @@
+package memstore
+
+import (
+	"context"
+	"slices"
+
+	"github.com/Specialized101/chirpy/internal/database"
+)
+
+func (s *Store) CreateFollow(ctx context.Context, arg database.CreateFollowParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[arg.FollowerID]; !ok {
+		return foreignKeyError("follows", "follower_id")
+	}
+	if _, ok := s.users[arg.FolloweeID]; !ok {
+		return foreignKeyError("follows", "followee_id")
+	}
+	key := followKey{followerID: arg.FollowerID, followeeID: arg.FolloweeID}
+	if _, ok := s.follows[key]; ok {
+		return nil
+	}
+	s.follows[key] = database.Follow(arg)
+	return nil
+}
+
+func (s *Store) DeleteFollow(ctx context.Context, arg database.DeleteFollowParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.follows, followKey{followerID: arg.FollowerID, followeeID: arg.FolloweeID})
+	return nil
+}
+
+func (s *Store) GetFollowers(ctx context.Context, arg database.GetFollowersParams) ([]database.GetFollowersRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var items []database.GetFollowersRow
+	for _, f := range s.follows {
+		if f.FolloweeID != arg.UserID {
+			continue
+		}
+		if arg.BeforeCreatedAt.Valid &&
+			compareKeys(f.CreatedAt, f.FollowerID, arg.BeforeCreatedAt.Time, arg.BeforeID.UUID) >= 0 {
+			continue
+		}
+		items = append(items, database.GetFollowersRow{FollowerID: f.FollowerID, CreatedAt: f.CreatedAt})
+	}
+	slices.SortFunc(items, func(a, b database.GetFollowersRow) int {
+		return compareKeys(b.CreatedAt, b.FollowerID, a.CreatedAt, a.FollowerID)
+	})
+	return limit(items, arg.PageLimit), nil
+}
+
+func (s *Store) GetFollowing(ctx context.Context, arg database.GetFollowingParams) ([]database.GetFollowingRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var items []database.GetFollowingRow
+	for _, f := range s.follows {
+		if f.FollowerID != arg.UserID {
+			continue
+		}
+		if arg.BeforeCreatedAt.Valid &&
+			compareKeys(f.CreatedAt, f.FolloweeID, arg.BeforeCreatedAt.Time, arg.BeforeID.UUID) >= 0 {
+			continue
+		}
+		items = append(items, database.GetFollowingRow{FolloweeID: f.FolloweeID, CreatedAt: f.CreatedAt})
+	}
+	slices.SortFunc(items, func(a, b database.GetFollowingRow) int {
+		return compareKeys(b.CreatedAt, b.FolloweeID, a.CreatedAt, a.FolloweeID)
+	})
+	return limit(items, arg.PageLimit), nil
+}
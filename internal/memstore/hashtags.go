@@ -0,0 +1,83 @@
+package memstore
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/Specialized101/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) SetChirpHashtags(ctx context.Context, arg database.SetChirpHashtagsParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.chirps[arg.ChirpID]; !ok && len(arg.Tags) > 0 {
+		return foreignKeyError("chirp_hashtags", "chirp_id")
+	}
+	for _, tag := range arg.Tags {
+		if _, ok := s.hashtags[tag]; !ok {
+			s.hashtags[tag] = database.Hashtag{Tag: tag, CreatedAt: arg.TaggedAt}
+		}
+	}
+	for key := range s.chirpHashtags {
+		if key.chirpID == arg.ChirpID && !slices.Contains(arg.Tags, key.tag) {
+			delete(s.chirpHashtags, key)
+		}
+	}
+	for _, tag := range arg.Tags {
+		key := chirpHashtagKey{chirpID: arg.ChirpID, tag: tag}
+		if _, ok := s.chirpHashtags[key]; !ok {
+			s.chirpHashtags[key] = database.ChirpHashtag{ChirpID: arg.ChirpID, Tag: tag, CreatedAt: arg.TaggedAt}
+		}
+	}
+	return nil
+}
+
+func (s *Store) deleteHashtags(chirpID uuid.UUID) {
+	for key := range s.chirpHashtags {
+		if key.chirpID == chirpID {
+			delete(s.chirpHashtags, key)
+		}
+	}
+}
+
+func (s *Store) GetHashtagChirps(ctx context.Context, arg database.GetHashtagChirpsParams) ([]database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items := s.filterChirps(func(c database.Chirp) bool {
+		if _, ok := s.chirpHashtags[chirpHashtagKey{chirpID: c.ID, tag: arg.Tag}]; !ok || c.DeletedAt.Valid {
+			return false
+		}
+		if s.chirpsHidden(c.UserID) || s.muted(arg.ViewerID, c.UserID) {
+			return false
+		}
+		return !arg.BeforeCreatedAt.Valid ||
+			compareKeys(c.CreatedAt, c.ID, arg.BeforeCreatedAt.Time, arg.BeforeID.UUID) < 0
+	})
+	slices.SortFunc(items, func(a, b database.Chirp) int { return compareChirps(b, a) })
+	return limit(items, arg.PageLimit), nil
+}
+
+func (s *Store) GetTrendingHashtags(ctx context.Context, arg database.GetTrendingHashtagsParams) ([]database.GetTrendingHashtagsRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := map[string]int64{}
+	for _, ch := range s.chirpHashtags {
+		if !ch.CreatedAt.Before(arg.Since) {
+			counts[ch.Tag]++
+		}
+	}
+	var items []database.GetTrendingHashtagsRow
+	for tag, n := range counts {
+		items = append(items, database.GetTrendingHashtagsRow{Tag: tag, ChirpCount: n})
+	}
+	slices.SortFunc(items, func(a, b database.GetTrendingHashtagsRow) int {
+		if c := cmp.Compare(b.ChirpCount, a.ChirpCount); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Tag, b.Tag)
+	})
+	return limit(items, arg.PageLimit), nil
+}
@@ -0,0 +1,131 @@
+// Package memstore is an in-memory implementation of database.Querier. It
+// mirrors the behaviour of the queries in sql/queries closely enough to run
+// the HTTP handlers without Postgres, and is meant for tests.
+package memstore
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Specialized101/chirpy/internal/database"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type Store struct {
+	mu              sync.Mutex
+	users           map[uuid.UUID]database.User
+	chirps          map[uuid.UUID]database.Chirp
+	revisions       map[uuid.UUID]database.ChirpRevision
+	likes           map[likeKey]database.ChirpLike
+	hashtags        map[string]database.Hashtag
+	chirpHashtags   map[chirpHashtagKey]database.ChirpHashtag
+	mentions        map[mentionKey]database.Mention
+	moderationRules map[string]database.ModerationRule
+	chirpFlags      map[uuid.UUID]database.ChirpFlag
+	reports         map[uuid.UUID]database.Report
+	refreshTokens   map[string]database.RefreshToken
+	sessions        map[uuid.UUID]database.Session
+	follows         map[followKey]database.Follow
+	blocks          map[blockKey]database.Block
+	mutes           map[blockKey]database.Mute
+}
+
+type followKey struct {
+	followerID uuid.UUID
+	followeeID uuid.UUID
+}
+
+// blockKey keys both blocks and mutes, userID being the user who blocked or
+// muted targetID.
+type blockKey struct {
+	userID   uuid.UUID
+	targetID uuid.UUID
+}
+
+type likeKey struct {
+	userID  uuid.UUID
+	chirpID uuid.UUID
+}
+
+type chirpHashtagKey struct {
+	chirpID uuid.UUID
+	tag     string
+}
+
+type mentionKey struct {
+	chirpID uuid.UUID
+	userID  uuid.UUID
+}
+
+var _ database.Querier = (*Store)(nil)
+
+// New returns an empty store, but for the rows the migrations insert.
+func New() *Store {
+	s := &Store{
+		users:           map[uuid.UUID]database.User{},
+		chirps:          map[uuid.UUID]database.Chirp{},
+		revisions:       map[uuid.UUID]database.ChirpRevision{},
+		likes:           map[likeKey]database.ChirpLike{},
+		hashtags:        map[string]database.Hashtag{},
+		chirpHashtags:   map[chirpHashtagKey]database.ChirpHashtag{},
+		mentions:        map[mentionKey]database.Mention{},
+		moderationRules: map[string]database.ModerationRule{},
+		chirpFlags:      map[uuid.UUID]database.ChirpFlag{},
+		reports:         map[uuid.UUID]database.Report{},
+		refreshTokens:   map[string]database.RefreshToken{},
+		sessions:        map[uuid.UUID]database.Session{},
+		follows:         map[followKey]database.Follow{},
+		blocks:          map[blockKey]database.Block{},
+		mutes:           map[blockKey]database.Mute{},
+	}
+	for _, word := range []string{"kerfuffle", "sharbert", "fornax"} {
+		s.moderationRules[word] = database.ModerationRule{Word: word, Action: "mask", CreatedAt: now(), UpdatedAt: now()}
+	}
+	return s
+}
+
+// now stands in for NOW() in the queries.
+func now() time.Time {
+	return time.Now().UTC()
+}
+
+// foreignKeyError and uniqueError are *pq.Error, like the driver returns, so
+// callers can tell which constraint failed. The constraints have the names
+// Postgres gives them by default.
+func foreignKeyError(table, column string) error {
+	constraint := table + "_" + column + "_fkey"
+	return &pq.Error{
+		Code:       "23503",
+		Message:    fmt.Sprintf("insert or update on table %q violates foreign key constraint %q", table, constraint),
+		Table:      table,
+		Constraint: constraint,
+	}
+}
+
+func uniqueError(table, column string) error {
+	constraint := table + "_" + column + "_key"
+	return &pq.Error{
+		Code:       "23505",
+		Message:    fmt.Sprintf("duplicate key value violates unique constraint %q", constraint),
+		Table:      table,
+		Constraint: constraint,
+	}
+}
+
+// compareKeys orders rows the way Postgres orders (created_at, id) tuples.
+func compareKeys(aTime time.Time, aID uuid.UUID, bTime time.Time, bID uuid.UUID) int {
+	if c := aTime.Compare(bTime); c != 0 {
+		return c
+	}
+	return bytes.Compare(aID[:], bID[:])
+}
+
+func limit[T any](items []T, n int32) []T {
+	if int(n) < len(items) {
+		return items[:n]
+	}
+	return items
+}
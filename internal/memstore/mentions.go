@@ -0,0 +1,64 @@
+package memstore
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/Specialized101/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) SetChirpMentions(ctx context.Context, arg database.SetChirpMentionsParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	mentioned := map[uuid.UUID]bool{}
+	for _, u := range s.users {
+		if s.blocked(u.ID, arg.AuthorID) {
+			continue
+		}
+		if u.Username.Valid && slices.Contains(arg.Handles, strings.ToLower(u.Username.String)) {
+			mentioned[u.ID] = true
+		}
+	}
+	if _, ok := s.chirps[arg.ChirpID]; !ok && len(mentioned) > 0 {
+		return foreignKeyError("mentions", "chirp_id")
+	}
+	for key := range s.mentions {
+		if key.chirpID == arg.ChirpID && !mentioned[key.userID] {
+			delete(s.mentions, key)
+		}
+	}
+	for userID := range mentioned {
+		key := mentionKey{chirpID: arg.ChirpID, userID: userID}
+		if _, ok := s.mentions[key]; !ok {
+			s.mentions[key] = database.Mention{ChirpID: arg.ChirpID, UserID: userID, CreatedAt: arg.CreatedAt}
+		}
+	}
+	return nil
+}
+
+func (s *Store) deleteMentions(chirpID uuid.UUID) {
+	for key := range s.mentions {
+		if key.chirpID == chirpID {
+			delete(s.mentions, key)
+		}
+	}
+}
+
+func (s *Store) GetUserMentions(ctx context.Context, arg database.GetUserMentionsParams) ([]database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items := s.filterChirps(func(c database.Chirp) bool {
+		if _, ok := s.mentions[mentionKey{chirpID: c.ID, userID: arg.UserID}]; !ok || c.DeletedAt.Valid {
+			return false
+		}
+		if s.chirpsHidden(c.UserID) || s.muted(uuid.NullUUID{UUID: arg.UserID, Valid: true}, c.UserID) {
+			return false
+		}
+		return !arg.BeforeCreatedAt.Valid ||
+			compareKeys(c.CreatedAt, c.ID, arg.BeforeCreatedAt.Time, arg.BeforeID.UUID) < 0
+	})
+	slices.SortFunc(items, func(a, b database.Chirp) int { return compareChirps(b, a) })
+	return limit(items, arg.PageLimit), nil
+}
@@ -0,0 +1,79 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+
+	"github.com/Specialized101/chirpy/internal/database"
+)
+
+func (s *Store) GetModerationRules(ctx context.Context) ([]database.ModerationRule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var items []database.ModerationRule
+	for _, r := range s.moderationRules {
+		items = append(items, r)
+	}
+	slices.SortFunc(items, func(a, b database.ModerationRule) int { return strings.Compare(a.Word, b.Word) })
+	return items, nil
+}
+
+func (s *Store) UpsertModerationRule(ctx context.Context, arg database.UpsertModerationRuleParams) (database.ModerationRule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.moderationRules[arg.Word]
+	if !ok {
+		r = database.ModerationRule{Word: arg.Word, CreatedAt: arg.CreatedAt}
+	}
+	r.Action = arg.Action
+	r.UpdatedAt = arg.CreatedAt
+	s.moderationRules[r.Word] = r
+	return r, nil
+}
+
+func (s *Store) DeleteModerationRule(ctx context.Context, word string) (database.ModerationRule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.moderationRules[word]
+	if !ok {
+		return database.ModerationRule{}, sql.ErrNoRows
+	}
+	delete(s.moderationRules, word)
+	return r, nil
+}
+
+func (s *Store) FlagChirp(ctx context.Context, arg database.FlagChirpParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.chirps[arg.ChirpID]; !ok {
+		return foreignKeyError("chirp_flags", "chirp_id")
+	}
+	s.chirpFlags[arg.ChirpID] = database.ChirpFlag{
+		ChirpID:   arg.ChirpID,
+		Words:     slices.Clone(arg.Words),
+		CreatedAt: arg.CreatedAt,
+	}
+	return nil
+}
+
+func (s *Store) GetFlaggedChirps(ctx context.Context, arg database.GetFlaggedChirpsParams) ([]database.GetFlaggedChirpsRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var items []database.GetFlaggedChirpsRow
+	for _, f := range s.chirpFlags {
+		c := s.chirps[f.ChirpID]
+		if c.DeletedAt.Valid {
+			continue
+		}
+		if arg.BeforeFlaggedAt.Valid && compareKeys(f.CreatedAt, f.ChirpID, arg.BeforeFlaggedAt.Time, arg.BeforeID.UUID) >= 0 {
+			continue
+		}
+		items = append(items, database.GetFlaggedChirpsRow{Chirp: c, Words: slices.Clone(f.Words), FlaggedAt: f.CreatedAt})
+	}
+	slices.SortFunc(items, func(a, b database.GetFlaggedChirpsRow) int {
+		return compareKeys(b.FlaggedAt, b.Chirp.ID, a.FlaggedAt, a.Chirp.ID)
+	})
+	return limit(items, arg.PageLimit), nil
+}
@@ -0,0 +1,94 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Specialized101/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateRefreshToken(ctx context.Context, arg database.CreateRefreshTokenParams) (database.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[arg.UserID]; !ok {
+		return database.RefreshToken{}, foreignKeyError("refresh_tokens", "user_id")
+	}
+	if _, ok := s.sessions[arg.FamilyID]; !ok {
+		return database.RefreshToken{}, foreignKeyError("refresh_tokens", "family_id")
+	}
+	if _, ok := s.refreshTokens[arg.Token]; ok {
+		return database.RefreshToken{}, uniqueError("refresh_tokens", "token")
+	}
+	rt := database.RefreshToken{
+		Token:     arg.Token,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		ExpiresAt: arg.ExpiresAt,
+		RevokedAt: arg.RevokedAt,
+		UserID:    arg.UserID,
+		FamilyID:  arg.FamilyID,
+	}
+	s.refreshTokens[rt.Token] = rt
+	return rt, nil
+}
+
+func (s *Store) GetRefreshToken(ctx context.Context, token string) (database.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rt, ok := s.refreshTokens[token]
+	if !ok {
+		return database.RefreshToken{}, sql.ErrNoRows
+	}
+	return rt, nil
+}
+
+func (s *Store) RevokeRefreshToken(ctx context.Context, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revokeRefreshTokens(func(rt database.RefreshToken) bool {
+		return rt.Token == token
+	})
+	return nil
+}
+
+func (s *Store) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revokeRefreshTokens(func(rt database.RefreshToken) bool {
+		return rt.FamilyID == familyID && !rt.RevokedAt.Valid
+	})
+	return nil
+}
+
+func (s *Store) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revokeRefreshTokens(func(rt database.RefreshToken) bool {
+		return rt.UserID == userID && !rt.RevokedAt.Valid
+	})
+	return nil
+}
+
+func (s *Store) RotateRefreshToken(ctx context.Context, token string) (database.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rt, ok := s.refreshTokens[token]
+	if !ok || rt.RevokedAt.Valid || rt.RotatedAt.Valid {
+		return database.RefreshToken{}, sql.ErrNoRows
+	}
+	rt.UpdatedAt = now()
+	rt.RotatedAt = sql.NullTime{Time: now(), Valid: true}
+	s.refreshTokens[token] = rt
+	return rt, nil
+}
+
+func (s *Store) revokeRefreshTokens(match func(database.RefreshToken) bool) {
+	for token, rt := range s.refreshTokens {
+		if match(rt) {
+			rt.UpdatedAt = now()
+			rt.RevokedAt = sql.NullTime{Time: now(), Valid: true}
+			s.refreshTokens[token] = rt
+		}
+	}
+}
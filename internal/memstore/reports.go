@@ -0,0 +1,125 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+
+	"github.com/Specialized101/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateReport(ctx context.Context, arg database.CreateReportParams) (database.Report, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.reports[arg.ID]; ok {
+		return database.Report{}, uniqueError("reports", "id")
+	}
+	if _, ok := s.users[arg.ReporterID.UUID]; arg.ReporterID.Valid && !ok {
+		return database.Report{}, foreignKeyError("reports", "reporter_id")
+	}
+	if _, ok := s.users[arg.UserID]; !ok {
+		return database.Report{}, foreignKeyError("reports", "user_id")
+	}
+	if _, ok := s.chirps[arg.ChirpID.UUID]; arg.ChirpID.Valid && !ok {
+		return database.Report{}, foreignKeyError("reports", "chirp_id")
+	}
+	report := database.Report{
+		ID:         arg.ID,
+		CreatedAt:  arg.CreatedAt,
+		UpdatedAt:  arg.CreatedAt,
+		ReporterID: arg.ReporterID,
+		UserID:     arg.UserID,
+		ChirpID:    arg.ChirpID,
+		ChirpBody:  arg.ChirpBody,
+		Reason:     arg.Reason,
+		Details:    arg.Details,
+	}
+	s.reports[report.ID] = report
+	return report, nil
+}
+
+func (s *Store) GetReportByID(ctx context.Context, id uuid.UUID) (database.Report, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.reports[id]
+	if !ok {
+		return database.Report{}, sql.ErrNoRows
+	}
+	return r, nil
+}
+
+func (s *Store) GetReports(ctx context.Context, arg database.GetReportsParams) ([]database.Report, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var items []database.Report
+	for _, r := range s.reports {
+		var match bool
+		switch arg.Status {
+		case "open":
+			match = !r.ClaimedAt.Valid && !r.ResolvedAt.Valid
+		case "claimed":
+			match = r.ClaimedAt.Valid && !r.ResolvedAt.Valid
+		default:
+			match = r.ResolvedAt.Valid
+		}
+		if !match {
+			continue
+		}
+		if arg.AfterCreatedAt.Valid && compareKeys(r.CreatedAt, r.ID, arg.AfterCreatedAt.Time, arg.AfterID.UUID) <= 0 {
+			continue
+		}
+		items = append(items, r)
+	}
+	slices.SortFunc(items, func(a, b database.Report) int {
+		return compareKeys(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+	})
+	return limit(items, arg.PageLimit), nil
+}
+
+func (s *Store) ClaimReport(ctx context.Context, arg database.ClaimReportParams) (database.Report, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.claimableReport(arg.ID, arg.ModeratorID)
+	if !ok {
+		return database.Report{}, sql.ErrNoRows
+	}
+	r.ClaimedBy = uuid.NullUUID{UUID: arg.ModeratorID, Valid: true}
+	if !r.ClaimedAt.Valid {
+		r.ClaimedAt = sql.NullTime{Time: arg.ClaimedAt, Valid: true}
+	}
+	r.UpdatedAt = arg.ClaimedAt
+	s.reports[r.ID] = r
+	return r, nil
+}
+
+func (s *Store) ResolveReport(ctx context.Context, arg database.ResolveReportParams) (database.Report, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.claimableReport(arg.ID, arg.ModeratorID)
+	if !ok {
+		return database.Report{}, sql.ErrNoRows
+	}
+	moderator := uuid.NullUUID{UUID: arg.ModeratorID, Valid: true}
+	r.ClaimedBy = moderator
+	if !r.ClaimedAt.Valid {
+		r.ClaimedAt = sql.NullTime{Time: arg.ResolvedAt, Valid: true}
+	}
+	r.ResolvedBy = moderator
+	r.ResolvedAt = sql.NullTime{Time: arg.ResolvedAt, Valid: true}
+	r.Resolution = sql.NullString{String: arg.Resolution, Valid: true}
+	r.ResolutionNote = arg.ResolutionNote
+	r.UpdatedAt = arg.ResolvedAt
+	s.reports[r.ID] = r
+	return r, nil
+}
+
+// claimableReport returns the report when it is unresolved and claimed by
+// nobody or by the moderator.
+func (s *Store) claimableReport(id, moderatorID uuid.UUID) (database.Report, bool) {
+	r, ok := s.reports[id]
+	if !ok || r.ResolvedAt.Valid || (r.ClaimedBy.Valid && r.ClaimedBy.UUID != moderatorID) {
+		return database.Report{}, false
+	}
+	return r, true
+}
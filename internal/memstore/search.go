@@ -0,0 +1,179 @@
+package memstore
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/Specialized101/chirpy/internal/database"
+)
+
+// The full-text search below stands in for the english configuration of
+// Postgres without its dictionaries: words are lower cased but not stemmed,
+// and there are no stop words. The rank only grows with the number of
+// matches, like ts_rank does for chirps of similar length.
+
+func (s *Store) SearchChirps(ctx context.Context, arg database.SearchChirpsParams) ([]database.SearchChirpsRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	terms := parseSearch(arg.Query)
+	var items []database.SearchChirpsRow
+	for _, c := range s.chirps {
+		if c.DeletedAt.Valid || s.chirpsHidden(c.UserID) || s.muted(arg.ViewerID, c.UserID) {
+			continue
+		}
+		words := splitWords(c.Body)
+		if !matchSearch(terms, words) {
+			continue
+		}
+		rank, headline := highlight(c.Body, terms, words)
+		if arg.BeforeRank.Valid {
+			before := float32(arg.BeforeRank.Float64)
+			if rank > before || rank == before && bytes.Compare(c.ID[:], arg.BeforeID.UUID[:]) >= 0 {
+				continue
+			}
+		}
+		items = append(items, database.SearchChirpsRow{Chirp: c, Rank: rank, Headline: headline})
+	}
+	slices.SortFunc(items, func(a, b database.SearchChirpsRow) int {
+		if c := cmp.Compare(b.Rank, a.Rank); c != 0 {
+			return c
+		}
+		return bytes.Compare(b.Chirp.ID[:], a.Chirp.ID[:])
+	})
+	return limit(items, arg.PageLimit), nil
+}
+
+// searchTerm is one condition of a websearch_to_tsquery query: any of its
+// phrases must appear, or none when it is negated.
+type searchTerm struct {
+	phrases [][]string
+	negated bool
+}
+
+// parseSearch reads the web search syntax: words and "quoted phrases" must
+// all appear, "or" between two of them lets either do, and a leading '-'
+// excludes one.
+func parseSearch(query string) []searchTerm {
+	var terms []searchTerm
+	either := false
+	rest := query
+	for {
+		rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+		if rest == "" {
+			break
+		}
+		negated := false
+		if rest[0] == '-' {
+			negated = true
+			rest = rest[1:]
+		}
+		var token string
+		quoted := false
+		if strings.HasPrefix(rest, `"`) {
+			quoted = true
+			token, rest, _ = strings.Cut(rest[1:], `"`)
+		} else {
+			end := strings.IndexFunc(rest, func(r rune) bool { return unicode.IsSpace(r) || r == '"' })
+			if end < 0 {
+				end = len(rest)
+			}
+			token, rest = rest[:end], rest[end:]
+		}
+		if !quoted && !negated && strings.EqualFold(token, "or") {
+			either = len(terms) > 0
+			continue
+		}
+		var phrase []string
+		for _, w := range splitWords(token) {
+			phrase = append(phrase, w.text)
+		}
+		if len(phrase) == 0 {
+			continue
+		}
+		if either && !negated && !terms[len(terms)-1].negated {
+			last := &terms[len(terms)-1]
+			last.phrases = append(last.phrases, phrase)
+		} else {
+			terms = append(terms, searchTerm{phrases: [][]string{phrase}, negated: negated})
+		}
+		either = false
+	}
+	return terms
+}
+
+type word struct {
+	text       string
+	start, end int
+}
+
+// splitWords returns the lower cased words of text with where they are.
+func splitWords(text string) []word {
+	var words []word
+	start := -1
+	for i, r := range text + " " {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if isWord && start < 0 {
+			start = i
+		}
+		if !isWord && start >= 0 {
+			words = append(words, word{text: strings.ToLower(text[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+	return words
+}
+
+func matchSearch(terms []searchTerm, words []word) bool {
+	positive := false
+	for _, t := range terms {
+		found := slices.ContainsFunc(t.phrases, func(p []string) bool { return containsPhrase(words, p) })
+		if found == t.negated {
+			return false
+		}
+		positive = positive || !t.negated
+	}
+	return positive
+}
+
+func containsPhrase(words []word, phrase []string) bool {
+	for i := 0; i+len(phrase) <= len(words); i++ {
+		if slices.EqualFunc(words[i:i+len(phrase)], phrase, func(w word, p string) bool { return w.text == p }) {
+			return true
+		}
+	}
+	return false
+}
+
+// highlight wraps every word of body the query looks for between the markers
+// of ts_headline, and counts them for the rank.
+func highlight(body string, terms []searchTerm, words []word) (float32, string) {
+	wanted := map[string]bool{}
+	for _, t := range terms {
+		if t.negated {
+			continue
+		}
+		for _, p := range t.phrases {
+			for _, w := range p {
+				wanted[w] = true
+			}
+		}
+	}
+	var b strings.Builder
+	matches := 0
+	prev := 0
+	for _, w := range words {
+		if !wanted[w.text] {
+			continue
+		}
+		matches++
+		b.WriteString(body[prev:w.start])
+		b.WriteString("\x02" + body[w.start:w.end] + "\x03")
+		prev = w.end
+	}
+	b.WriteString(body[prev:])
+	return float32(matches) / 10, b.String()
+}
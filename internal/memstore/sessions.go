@@ -0,0 +1,70 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+
+	"github.com/Specialized101/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateSession(ctx context.Context, arg database.CreateSessionParams) (database.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[arg.UserID]; !ok {
+		return database.Session{}, foreignKeyError("sessions", "user_id")
+	}
+	if _, ok := s.sessions[arg.ID]; ok {
+		return database.Session{}, uniqueError("sessions", "id")
+	}
+	session := database.Session(arg)
+	s.sessions[session.ID] = session
+	return session, nil
+}
+
+func (s *Store) GetActiveSessionsByUserID(ctx context.Context, userID uuid.UUID) ([]database.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	active := map[uuid.UUID]bool{}
+	for _, rt := range s.refreshTokens {
+		if !rt.RevokedAt.Valid && !rt.RotatedAt.Valid && rt.ExpiresAt.After(now()) {
+			active[rt.FamilyID] = true
+		}
+	}
+	var items []database.Session
+	for _, session := range s.sessions {
+		if session.UserID == userID && active[session.ID] {
+			items = append(items, session)
+		}
+	}
+	slices.SortFunc(items, func(a, b database.Session) int {
+		return b.LastUsedAt.Compare(a.LastUsedAt)
+	})
+	return items, nil
+}
+
+func (s *Store) GetSessionByID(ctx context.Context, id uuid.UUID) (database.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[id]
+	if !ok {
+		return database.Session{}, sql.ErrNoRows
+	}
+	return session, nil
+}
+
+func (s *Store) TouchSession(ctx context.Context, arg database.TouchSessionParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[arg.ID]
+	if !ok {
+		return nil
+	}
+	session.UpdatedAt = now()
+	session.LastUsedAt = now()
+	session.UserAgent = arg.UserAgent
+	session.IpAddress = arg.IpAddress
+	s.sessions[session.ID] = session
+	return nil
+}
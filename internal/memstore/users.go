@@ -0,0 +1,254 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Specialized101/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[arg.ID]; ok {
+		return database.User{}, uniqueError("users", "id")
+	}
+	if s.emailTaken(arg.Email, uuid.Nil) {
+		return database.User{}, uniqueError("users", "email")
+	}
+	if s.usernameTaken(arg.Username, uuid.Nil) {
+		return database.User{}, uniqueError("users", "username")
+	}
+	user := database.User{
+		ID:             arg.ID,
+		CreatedAt:      arg.CreatedAt,
+		UpdatedAt:      arg.UpdatedAt,
+		Email:          arg.Email,
+		HashedPassword: arg.HashedPassword,
+		Username:       arg.Username,
+		Role:           "user",
+	}
+	s.users[user.ID] = user
+	return user, nil
+}
+
+// DeleteUsers also clears every table referencing users, like the ON DELETE
+// CASCADE foreign keys do.
+func (s *Store) DeleteUsers(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.users)
+	clear(s.chirps)
+	clear(s.revisions)
+	clear(s.likes)
+	clear(s.chirpHashtags)
+	clear(s.mentions)
+	clear(s.chirpFlags)
+	clear(s.reports)
+	clear(s.refreshTokens)
+	clear(s.sessions)
+	clear(s.follows)
+	clear(s.blocks)
+	clear(s.mutes)
+	return nil
+}
+
+func (s *Store) GetUserByEmail(ctx context.Context, email string) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range s.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return database.User{}, sql.ErrNoRows
+}
+
+func (s *Store) GetUserByID(ctx context.Context, id uuid.UUID) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[id]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+	return u, nil
+}
+
+func (s *Store) GetUserByUsername(ctx context.Context, username string) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range s.users {
+		if u.Username.Valid && strings.EqualFold(u.Username.String, username) {
+			return u, nil
+		}
+	}
+	return database.User{}, sql.ErrNoRows
+}
+
+func (s *Store) SearchUsers(ctx context.Context, arg database.SearchUsersParams) ([]database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var items []database.User
+	for _, u := range s.users {
+		name := strings.ToLower(u.Username.String)
+		if !u.Username.Valid || !like(name, arg.Pattern) {
+			continue
+		}
+		if arg.AfterUsername.Valid && name <= arg.AfterUsername.String {
+			continue
+		}
+		items = append(items, u)
+	}
+	slices.SortFunc(items, func(a, b database.User) int {
+		return strings.Compare(strings.ToLower(a.Username.String), strings.ToLower(b.Username.String))
+	})
+	return limit(items, arg.PageLimit), nil
+}
+
+// like matches s against a LIKE pattern: '%' stands for any run of
+// characters, '_' for one, and '\' escapes the next character.
+func like(s, pattern string) bool {
+	if pattern == "" {
+		return s == ""
+	}
+	switch c, size := utf8.DecodeRuneInString(pattern); c {
+	case '%':
+		for i := 0; i <= len(s); i++ {
+			if like(s[i:], pattern[size:]) {
+				return true
+			}
+		}
+		return false
+	case '_':
+		if s == "" {
+			return false
+		}
+		_, n := utf8.DecodeRuneInString(s)
+		return like(s[n:], pattern[size:])
+	case '\\':
+		if len(pattern) > 1 {
+			pattern = pattern[1:]
+		}
+		fallthrough
+	default:
+		c, size = utf8.DecodeRuneInString(pattern)
+		r, n := utf8.DecodeRuneInString(s)
+		return s != "" && r == c && like(s[n:], pattern[size:])
+	}
+}
+
+func (s *Store) UpdateUser(ctx context.Context, arg database.UpdateUserParams) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[arg.ID]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+	if s.emailTaken(arg.Email, arg.ID) {
+		return database.User{}, uniqueError("users", "email")
+	}
+	if s.usernameTaken(arg.Username, arg.ID) {
+		return database.User{}, uniqueError("users", "username")
+	}
+	u.Email = arg.Email
+	u.HashedPassword = arg.HashedPassword
+	if arg.Username.Valid {
+		u.Username = arg.Username
+	}
+	u.UpdatedAt = now()
+	s.users[u.ID] = u
+	return u, nil
+}
+
+func (s *Store) SetUserRole(ctx context.Context, arg database.SetUserRoleParams) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[arg.ID]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+	u.Role = arg.Role
+	u.UpdatedAt = now()
+	s.users[u.ID] = u
+	return u, nil
+}
+
+func (s *Store) SuspendUser(ctx context.Context, arg database.SuspendUserParams) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[arg.ID]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+	u.SuspendedAt = sql.NullTime{Time: arg.SuspendedAt, Valid: true}
+	u.SuspendedUntil = arg.SuspendedUntil
+	u.SuspensionReason = arg.SuspensionReason
+	u.Banned = arg.Banned
+	u.ChirpsHidden = arg.ChirpsHidden
+	u.UpdatedAt = now()
+	s.users[u.ID] = u
+	return u, nil
+}
+
+func (s *Store) LiftSuspension(ctx context.Context, id uuid.UUID) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[id]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+	u.SuspendedAt = sql.NullTime{}
+	u.SuspendedUntil = sql.NullTime{}
+	u.SuspensionReason = ""
+	u.Banned = false
+	u.ChirpsHidden = false
+	u.UpdatedAt = now()
+	s.users[u.ID] = u
+	return u, nil
+}
+
+// chirpsHidden reports whether the user is suspended with their chirps
+// hidden.
+func (s *Store) chirpsHidden(userID uuid.UUID) bool {
+	u := s.users[userID]
+	return u.ChirpsHidden && (!u.SuspendedUntil.Valid || u.SuspendedUntil.Time.After(now()))
+}
+
+func (s *Store) UpgradeUser(ctx context.Context, id uuid.UUID) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[id]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+	u.IsChirpyRed = true
+	u.UpdatedAt = now()
+	s.users[u.ID] = u
+	return u, nil
+}
+
+func (s *Store) emailTaken(email string, except uuid.UUID) bool {
+	for _, u := range s.users {
+		if u.Email == email && u.ID != except {
+			return true
+		}
+	}
+	return false
+}
+
+// usernameTaken compares usernames like the unique index on lower(username).
+func (s *Store) usernameTaken(username sql.NullString, except uuid.UUID) bool {
+	if !username.Valid {
+		return false
+	}
+	for _, u := range s.users {
+		if u.Username.Valid && strings.EqualFold(u.Username.String, username.String) && u.ID != except {
+			return true
+		}
+	}
+	return false
+}
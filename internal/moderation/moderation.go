@@ -0,0 +1,176 @@
+// Package moderation checks chirps against lists of words, each with the
+// action to take when a chirp uses it. Words are matched after Normalize, so
+// a list entry also catches its capitalized, accented and leetspeak
+// spellings.
+package moderation
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Action is what happens to a chirp using a word.
+type Action string
+
+const (
+	// Mask replaces the word with asterisks.
+	Mask Action = "mask"
+	// Flag keeps the chirp as is and puts it up for review.
+	Flag Action = "flag"
+	// Reject refuses the chirp.
+	Reject Action = "reject"
+)
+
+// mask is what a masked word is replaced with, whatever its length.
+const mask = "****"
+
+// severity orders the actions, the most severe one used by a chirp wins.
+var severity = map[Action]int{Mask: 1, Flag: 2, Reject: 3}
+
+// ParseAction returns the action named s.
+func ParseAction(s string) (Action, error) {
+	a := Action(s)
+	if _, ok := severity[a]; !ok {
+		return "", fmt.Errorf("action must be %s, %s or %s", Mask, Flag, Reject)
+	}
+	return a, nil
+}
+
+type Rule struct {
+	Word   string
+	Action Action
+}
+
+// ParseRules reads a word list: one word per line, optionally followed by its
+// action, mask by default. Blank lines and lines starting with '#' are
+// skipped.
+func ParseRules(r io.Reader) ([]Rule, error) {
+	var rules []Rule
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("line %d: expected a word and an action", line)
+		}
+		rule := Rule{Word: Normalize(fields[0]), Action: Mask}
+		if rule.Word == "" {
+			return nil, fmt.Errorf("line %d: %q is not a word", line, fields[0])
+		}
+		if len(fields) == 2 {
+			action, err := ParseAction(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			rule.Action = action
+		}
+		rules = append(rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// Filter holds the rules in force. Its methods are safe to call
+// concurrently, so the rules can be replaced while chirps are checked.
+type Filter struct {
+	mu    sync.RWMutex
+	rules map[string]Action
+}
+
+func NewFilter(rules []Rule) *Filter {
+	f := &Filter{}
+	f.Replace(rules)
+	return f
+}
+
+// Replace swaps the rules for new ones. When a word comes up more than once,
+// the last rule wins.
+func (f *Filter) Replace(rules []Rule) {
+	m := make(map[string]Action, len(rules))
+	for _, r := range rules {
+		m[Normalize(r.Word)] = r.Action
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = m
+}
+
+// Rules returns the rules in force, sorted by word.
+func (f *Filter) Rules() []Rule {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	rules := make([]Rule, 0, len(f.rules))
+	for word, action := range f.rules {
+		rules = append(rules, Rule{Word: word, Action: action})
+	}
+	slices.SortFunc(rules, func(a, b Rule) int { return strings.Compare(a.Word, b.Word) })
+	return rules
+}
+
+// Result is the outcome of checking a text.
+type Result struct {
+	// Text is the text with the masked words replaced.
+	Text string
+	// Action is the most severe action of the words found, empty when the
+	// text uses none.
+	Action Action
+	// Matches lists the rules the text broke, in the order it broke them.
+	Matches []Rule
+}
+
+// Words returns the words of the matches with the given action.
+func (r Result) Words(action Action) []string {
+	var words []string
+	for _, m := range r.Matches {
+		if m.Action == action && !slices.Contains(words, m.Word) {
+			words = append(words, m.Word)
+		}
+	}
+	return words
+}
+
+// Check looks for the words of the rules in text. A symbol standing in for a
+// letter right before a word, as in "$tuff", belongs to the word when that
+// makes it match.
+func (f *Filter) Check(text string) Result {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	var b strings.Builder
+	result := Result{}
+	prev := 0
+	for _, t := range Tokenize(text) {
+		start := t.Start
+		word := Normalize(t.Text)
+		action, ok := f.rules[word]
+		if r, size := utf8.DecodeLastRuneInString(text[:start]); !ok && leet[r] != 0 {
+			if a, found := f.rules[Normalize(text[start-size:t.End])]; found {
+				start -= size
+				word, action, ok = Normalize(text[start:t.End]), a, true
+			}
+		}
+		if !ok {
+			continue
+		}
+		result.Matches = append(result.Matches, Rule{Word: word, Action: action})
+		if severity[action] > severity[result.Action] {
+			result.Action = action
+		}
+		if action == Mask {
+			b.WriteString(text[prev:start])
+			b.WriteString(mask)
+			prev = t.End
+		}
+	}
+	b.WriteString(text[prev:])
+	result.Text = b.String()
+	return result
+}
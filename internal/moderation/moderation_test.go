@@ -0,0 +1,160 @@
+package moderation
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected string
+	}{
+		{input: "Fornax", expected: "fornax"},
+		{input: "KERFÜFFLE", expected: "kerfuffle"},
+		{input: "sh4rb3rt", expected: "sharbert"},
+		{input: "$harbert", expected: "sharbert"},
+		{input: "f.o.r.n.a.x", expected: "fornax"},
+		{input: "Kerfüffle", expected: "kerfuffle"},
+		{input: "naïve", expected: "naive"},
+		{input: "cafe\u0301", expected: "cafe"},
+		{input: "Phở", expected: "pho"},
+		{input: "ḱérfǘffle", expected: "kerfuffle"},
+		{input: "Łódź", expected: "lodz"},
+		{input: "Søren", expected: "soren"},
+		{input: "ά", expected: "α"},
+		{input: "й", expected: "и"},
+	}
+
+	for _, c := range cases {
+		if actual := Normalize(c.input); actual != c.expected {
+			t.Errorf("Normalize(%q): expected %q, received %q", c.input, c.expected, actual)
+		}
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected []string
+	}{
+		{input: "Fornax!", expected: []string{"Fornax"}},
+		{input: "don't stop", expected: []string{"don't", "stop"}},
+		{input: "f.o.o. bar", expected: []string{"f.o.o", "bar"}},
+		{input: "¿qué?¡sí!", expected: []string{"qué", "sí"}},
+		{input: "日本語 テキスト", expected: []string{"日本語", "テキスト"}},
+		{input: "snake_case, 1,000", expected: []string{"snake_case", "1", "000"}},
+		{input: "'quoted'", expected: []string{"quoted"}},
+	}
+
+	for _, c := range cases {
+		var actual []string
+		for _, tok := range Tokenize(c.input) {
+			if c.input[tok.Start:tok.End] != tok.Text {
+				t.Errorf("Tokenize(%q): token %q is not at %d:%d", c.input, tok.Text, tok.Start, tok.End)
+			}
+			actual = append(actual, tok.Text)
+		}
+		if fmt.Sprint(actual) != fmt.Sprint(c.expected) {
+			t.Errorf("Tokenize(%q): expected %q, received %q", c.input, c.expected, actual)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	f := NewFilter([]Rule{
+		{Word: "kerfuffle", Action: Mask},
+		{Word: "sharbert", Action: Mask},
+		{Word: "fornax", Action: Mask},
+		{Word: "spam", Action: Flag},
+		{Word: "Slur", Action: Reject},
+	})
+	cases := []struct {
+		input    string
+		expected string
+		action   Action
+	}{
+		{
+			input:    "I had something interesting for breakfast",
+			expected: "I had something interesting for breakfast",
+		},
+		{
+			input:    "I hear Mastodon is better than Chirpy. sharbert I need to migrate",
+			expected: "I hear Mastodon is better than Chirpy. **** I need to migrate",
+			action:   Mask,
+		},
+		{
+			input:    "I really need a kerfuffle to go to bed sooner, Fornax !",
+			expected: "I really need a **** to go to bed sooner, **** !",
+			action:   Mask,
+		},
+		{
+			input:    "I really need a KERFUFFLE to go to bed sooner, Fornax !",
+			expected: "I really need a **** to go to bed sooner, **** !",
+			action:   Mask,
+		},
+		{
+			input:    "I really need a kerfuffle to go to bed sooner, Fornax!",
+			expected: "I really need a **** to go to bed sooner, ****!",
+			action:   Mask,
+		},
+		{
+			input:    "k3rfüffl3, f.o.r.n.a.x and $harbert",
+			expected: "****, **** and ****",
+			action:   Mask,
+		},
+		{
+			input:    "@fornax and kerfuffles",
+			expected: "@**** and kerfuffles",
+			action:   Mask,
+		},
+		{
+			input:    "buy spam, kerfuffle",
+			expected: "buy spam, ****",
+			action:   Flag,
+		},
+		{
+			input:    "spam and a SLUR",
+			expected: "spam and a SLUR",
+			action:   Reject,
+		},
+	}
+
+	for _, c := range cases {
+		result := f.Check(c.input)
+		if result.Text != c.expected || result.Action != c.action {
+			t.Errorf("Check(%q): expected %q (%q), received %q (%q)", c.input, c.expected, c.action, result.Text, result.Action)
+		}
+	}
+	if words := f.Check("spam spam kerfuffle").Words(Flag); fmt.Sprint(words) != "[spam]" {
+		t.Errorf("expected the flagged word once, received %q", words)
+	}
+
+	f.Replace([]Rule{{Word: "breakfast", Action: Mask}})
+	if result := f.Check("kerfuffle for breakfast"); result.Text != "kerfuffle for ****" {
+		t.Errorf("expected the new rules only, received %q", result.Text)
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules(strings.NewReader(`
+# masked by default
+Kerfuffle
+spam   flag
+
+sl*ur reject
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "[{kerfuffle mask} {spam flag} {slur reject}]"; fmt.Sprint(rules) != expected {
+		t.Errorf("expected %s, received %v", expected, rules)
+	}
+
+	for _, input := range []string{"word delete", "word mask extra", "!!!"} {
+		if _, err := ParseRules(strings.NewReader(input)); err == nil {
+			t.Errorf("expected an error for %q", input)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package moderation
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Token is a word of a text and where it is, in bytes.
+type Token struct {
+	Text       string
+	Start, End int
+}
+
+// Tokenize splits text into words, a simplified take on the word boundaries
+// of Unicode (UAX #29): a word is a run of letters, marks, digits and
+// underscores, and keeps going across an apostrophe, a period or a colon
+// between two of them, so "don't" and "f.o.o" are one word each. Everything
+// else separates words, whatever the script.
+func Tokenize(text string) []Token {
+	var tokens []Token
+	start := -1
+	for i, r := range text {
+		if isWordRune(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start < 0 {
+			continue
+		}
+		if isMidWordRune(r) {
+			next, _ := utf8.DecodeRuneInString(text[i+utf8.RuneLen(r):])
+			if isWordRune(next) {
+				continue
+			}
+		}
+		tokens = append(tokens, Token{Text: text[start:i], Start: start, End: i})
+		start = -1
+	}
+	if start >= 0 {
+		tokens = append(tokens, Token{Text: text[start:], Start: start, End: len(text)})
+	}
+	return tokens
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsMark(r) || unicode.IsDigit(r) || r == '_'
+}
+
+func isMidWordRune(r rune) bool {
+	switch r {
+	case '\'', '’', '.', ':', '·':
+		return true
+	}
+	return false
+}
+
+// leet maps the digits and symbols that stand in for letters.
+var leet = map[rune]rune{
+	'0': 'o',
+	'1': 'i',
+	'3': 'e',
+	'4': 'a',
+	'5': 's',
+	'7': 't',
+	'@': 'a',
+	'$': 's',
+}
+
+// strokes folds the Latin letters Unicode does not decompose, their stroke
+// or dot is part of the letter, to the letter they are built on.
+var strokes = map[rune]rune{
+	'đ': 'd',
+	'ħ': 'h',
+	'ı': 'i',
+	'ŀ': 'l',
+	'ł': 'l',
+	'ŉ': 'n',
+	'ø': 'o',
+	'ŧ': 't',
+}
+
+// Normalize reduces a word to the form rules are matched on: lower case,
+// without diacritics, with leetspeak read as letters and without the
+// punctuation a word may hold. "K3rfüffl.e" becomes "kerfuffle". Diacritics
+// are dropped after the canonical decomposition (NFD) of the word, which
+// splits accented letters of every script into a letter and its marks.
+func Normalize(word string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(word) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		r = unicode.ToLower(r)
+		if l, ok := leet[r]; ok {
+			r = l
+		}
+		if base, ok := strokes[r]; ok {
+			r = base
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -1,40 +1,46 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
-	"slices"
 	"strings"
-	"sync/atomic"
 	"time"
 
 	"github.com/Specialized101/chirpy/internal/auth"
 	"github.com/Specialized101/chirpy/internal/database"
+	"github.com/Specialized101/chirpy/internal/moderation"
 	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
 
-const (
-	ADDR = "127.0.0.1"
-	PORT = "8080"
-)
-
 type apiConfig struct {
-	fileserverHits atomic.Int32
-	db             *database.Queries
-	platform       string
-	secret         string
-	polkaKey       string
+	db                  database.Querier
+	platform            string
+	secret              string
+	polkaKey            string
+	accessTokenTTL      time.Duration
+	refreshTokenTTL     time.Duration
+	maxChirpLength      int
+	chirpEditWindow     time.Duration
+	trendingWindow      time.Duration
+	moderation          *moderation.Filter
+	moderationWordsFile string
+	logger              *slog.Logger
+	metrics             *metrics
 }
 
 func (cfg *apiConfig) middlewareMetricsInc(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		cfg.fileserverHits.Add(1)
+		cfg.metrics.fileserverHits.Inc()
 		next.ServeHTTP(w, r)
 	})
 }
@@ -47,20 +53,23 @@ func (cfg *apiConfig) handlerMetrics(w http.ResponseWriter, r *http.Request) {
 <html>
 	<body>
 		<h1>Welcome, Chirpy Admin</h1>
-		<p>Chirpy has been visited %d times!</p>
+		<p>Chirpy has been visited %.0f times!</p>
 	</body>
 </html>
-	`, cfg.fileserverHits.Load())
+	`, cfg.metrics.gaugeValue("chirpy_fileserver_hits"))
 	w.Write([]byte(body))
 }
 
+// handlerReset deletes every user and what they posted. On top of the admin
+// role, it needs the dev platform, so a production database cannot be wiped
+// by mistake.
 func (cfg *apiConfig) handlerReset(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 	if cfg.platform != "dev" {
 		w.WriteHeader(http.StatusForbidden)
 		return
 	}
-	cfg.fileserverHits.Store(0)
+	cfg.metrics.fileserverHits.Set(0)
 	if err := cfg.db.DeleteUsers(r.Context()); err != nil {
 		log.Printf("reset error: %v", err)
 		w.WriteHeader(http.StatusInternalServerError)
@@ -74,14 +83,17 @@ func (cfg *apiConfig) handlerReset(w http.ResponseWriter, r *http.Request) {
 func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 	type reqParams struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		Email    string  `json:"email"`
+		Password string  `json:"password"`
+		Username *string `json:"username"`
 	}
 	type returnVals struct {
 		ID          uuid.UUID `json:"id"`
 		CreatedAt   time.Time `json:"created_at"`
 		UpdatedAt   time.Time `json:"updated_at"`
 		Email       string    `json:"email"`
+		Username    *string   `json:"username"`
+		Role        string    `json:"role"`
 		IsChirpyRed bool      `json:"is_chirpy_red"`
 	}
 	decoder := json.NewDecoder(r.Body)
@@ -98,6 +110,11 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request)
 		_ = respondWithError(w, http.StatusBadRequest, "password is required")
 		return
 	}
+	username, err := parseUsername(params.Username)
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	hashedPwd, err := auth.HashPassword(params.Password)
 	if err != nil {
 		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
@@ -109,7 +126,12 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request)
 		UpdatedAt:      time.Now().UTC(),
 		Email:          params.Email,
 		HashedPassword: hashedPwd,
+		Username:       username,
 	})
+	if usernameTaken(err) {
+		_ = respondWithError(w, http.StatusConflict, "username is already taken")
+		return
+	}
 	if err != nil {
 		log.Printf("failed to create user: %v\n", err)
 		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
@@ -120,34 +142,35 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request)
 		CreatedAt:   user.CreatedAt,
 		UpdatedAt:   user.UpdatedAt,
 		Email:       user.Email,
+		Username:    nullableString(user.Username),
+		Role:        user.Role,
 		IsChirpyRed: user.IsChirpyRed,
 	})
 }
 
 func (cfg *apiConfig) handlerUpdateUser(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
-	accessToken, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		_ = respondWithError(w, http.StatusUnauthorized, "access token is missing/malformed in the header")
-		return
-	}
-	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
-	if err != nil {
-		_ = respondWithError(w, http.StatusUnauthorized, "access token is invalid")
-		return
-	}
+	caller, _ := userFromContext(r.Context())
+	// middlewareAuth already validated the token, it is echoed back as is.
+	accessToken, _ := auth.GetBearerToken(r.Header)
+	// Username is optional, the current one is kept without it.
 	type reqParams struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		Email    string  `json:"email"`
+		Password string  `json:"password"`
+		Username *string `json:"username"`
 	}
+	// There is no refresh_token: each session has its own, and the access
+	// token does not tell which session the caller is using. Clients keep the
+	// refresh token they got from POST /api/login or POST /api/refresh.
 	type returnVals struct {
-		ID           uuid.UUID `json:"id"`
-		CreatedAt    time.Time `json:"created_at"`
-		UpdatedAt    time.Time `json:"updated_at"`
-		Email        string    `json:"email"`
-		Token        string    `json:"token"`
-		RefreshToken string    `json:"refresh_token"`
-		IsChirpyRed  bool      `json:"is_chirpy_red"`
+		ID          uuid.UUID `json:"id"`
+		CreatedAt   time.Time `json:"created_at"`
+		UpdatedAt   time.Time `json:"updated_at"`
+		Email       string    `json:"email"`
+		Username    *string   `json:"username"`
+		Role        string    `json:"role"`
+		Token       string    `json:"token"`
+		IsChirpyRed bool      `json:"is_chirpy_red"`
 	}
 	decoder := json.NewDecoder(r.Body)
 	params := reqParams{}
@@ -156,6 +179,11 @@ func (cfg *apiConfig) handlerUpdateUser(w http.ResponseWriter, r *http.Request)
 		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
 		return
 	}
+	username, err := parseUsername(params.Username)
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	hashedPwd, err := auth.HashPassword(params.Password)
 	if err != nil {
 		log.Printf("failed to hash the password: %v", err)
@@ -165,28 +193,27 @@ func (cfg *apiConfig) handlerUpdateUser(w http.ResponseWriter, r *http.Request)
 	user, err := cfg.db.UpdateUser(r.Context(), database.UpdateUserParams{
 		Email:          params.Email,
 		HashedPassword: hashedPwd,
-		ID:             userID,
+		Username:       username,
+		ID:             caller.ID,
 	})
-	if err != nil {
-		log.Printf("failed to update user: %v", err)
-		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+	if usernameTaken(err) {
+		_ = respondWithError(w, http.StatusConflict, "username is already taken")
 		return
 	}
-
-	rt, err := cfg.db.GetRefreshTokenByUserId(r.Context(), user.ID)
 	if err != nil {
-		log.Printf("failed to get refresh token: %v", err)
+		log.Printf("failed to update user: %v", err)
 		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
 		return
 	}
 	_ = respondWithJSON(w, http.StatusOK, returnVals{
-		ID:           user.ID,
-		CreatedAt:    user.CreatedAt,
-		UpdatedAt:    user.UpdatedAt,
-		Email:        user.Email,
-		Token:        accessToken,
-		RefreshToken: rt.Token,
-		IsChirpyRed:  user.IsChirpyRed,
+		ID:          user.ID,
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+		Email:       user.Email,
+		Username:    nullableString(user.Username),
+		Role:        user.Role,
+		Token:       accessToken,
+		IsChirpyRed: user.IsChirpyRed,
 	})
 }
 
@@ -201,6 +228,8 @@ func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, r *http.Request) {
 		CreatedAt    time.Time `json:"created_at"`
 		UpdatedAt    time.Time `json:"updated_at"`
 		Email        string    `json:"email"`
+		Username     *string   `json:"username"`
+		Role         string    `json:"role"`
 		Token        string    `json:"token"`
 		RefreshToken string    `json:"refresh_token"`
 		IsChirpyRed  bool      `json:"is_chirpy_red"`
@@ -213,56 +242,57 @@ func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, r *http.Request) {
 	}
 	user, err := cfg.db.GetUserByEmail(r.Context(), params.Email)
 	if err != nil {
+		cfg.metrics.logins.WithLabelValues("failure").Inc()
 		_ = respondWithError(w, http.StatusUnauthorized, "Incorrect email or password")
 		return
 	}
 	if auth.CheckPasswordHash(params.Password, user.HashedPassword) != nil {
+		cfg.metrics.logins.WithLabelValues("failure").Inc()
 		_ = respondWithError(w, http.StatusUnauthorized, "Incorrect email or password")
 		return
 	}
-	token, err := auth.MakeJWT(user.ID, cfg.secret)
+	if userSuspended(user) {
+		cfg.metrics.logins.WithLabelValues("failure").Inc()
+		_ = respondWithError(w, http.StatusForbidden, suspensionMessage(user))
+		return
+	}
+	token, err := auth.MakeJWT(user.ID, auth.Role(user.Role), cfg.secret, cfg.accessTokenTTL)
 	if err != nil {
 		log.Printf("failed to create jwt token: %v\n", err)
 		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
 		return
 	}
-	var refreshToken string
-	rt, err := cfg.db.GetRefreshTokenByUserId(r.Context(), user.ID)
-	if err != nil && err == sql.ErrNoRows {
-		if err == sql.ErrNoRows {
-			refreshToken, err = auth.MakeRefreshToken()
-			if err != nil {
-				log.Printf("failed to create refresh token: %v\n", err)
-				_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
-				return
-			}
-			_, err := cfg.db.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
-				Token:     refreshToken,
-				CreatedAt: time.Now().UTC(),
-				UpdatedAt: time.Now().UTC(),
-				ExpiresAt: time.Now().Add(time.Hour).UTC(),
-				RevokedAt: sql.NullTime{},
-				UserID:    user.ID,
-			})
-			if err != nil {
-				log.Printf("failed to create refresh token in db: %v\n", err)
-				_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
-				return
-			}
-		} else {
-			log.Printf("failed to get refresh token from db: %v", err)
-			_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
-			return
-		}
-	} else {
-		refreshToken = rt.Token
+	// Every login starts a new session, and with it a new refresh token
+	// family, see handlerRefresh.
+	session, err := cfg.db.CreateSession(r.Context(), database.CreateSessionParams{
+		ID:         uuid.New(),
+		CreatedAt:  time.Now().UTC(),
+		UpdatedAt:  time.Now().UTC(),
+		LastUsedAt: time.Now().UTC(),
+		UserAgent:  r.UserAgent(),
+		IpAddress:  clientIP(r),
+		UserID:     user.ID,
+	})
+	if err != nil {
+		log.Printf("failed to create session: %v\n", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	refreshToken, err := cfg.issueRefreshToken(r.Context(), user.ID, session.ID)
+	if err != nil {
+		log.Printf("failed to create refresh token: %v\n", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
 	}
 
+	cfg.metrics.logins.WithLabelValues("success").Inc()
 	_ = respondWithJSON(w, http.StatusOK, returnVals{
 		ID:           user.ID,
 		CreatedAt:    user.CreatedAt,
 		UpdatedAt:    user.UpdatedAt,
 		Email:        user.Email,
+		Username:     nullableString(user.Username),
+		Role:         user.Role,
 		Token:        token,
 		RefreshToken: refreshToken,
 		IsChirpyRed:  user.IsChirpyRed,
@@ -270,21 +300,27 @@ func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// handlerRefresh exchanges a refresh token for a new access token and a new
+// refresh token. The presented token is retired and its replacement joins the
+// same family. A retired token being presented again means it leaked, so the
+// whole family is revoked and every holder has to log in again.
 func (cfg *apiConfig) handlerRefresh(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 	type returnVals struct {
-		Token string `json:"token"`
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
 	}
 	refreshToken, err := auth.GetBearerToken(r.Header)
 	if err != nil {
+		cfg.metrics.refreshes.WithLabelValues("invalid").Inc()
 		_ = respondWithError(w, http.StatusUnauthorized, "refresh token is required in the authorization header")
 		return
 	}
 	rt, err := cfg.db.GetRefreshToken(r.Context(), refreshToken)
-	fmt.Println(refreshToken)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			log.Printf("failed to get refresh token from db: %v", err)
+			cfg.metrics.refreshes.WithLabelValues("invalid").Inc()
 			_ = respondWithError(w, http.StatusUnauthorized, "refresh token has expired, revoked or does not exist")
 		} else {
 			log.Printf("failed to get refresh token from db: %v", err)
@@ -293,23 +329,96 @@ func (cfg *apiConfig) handlerRefresh(w http.ResponseWriter, r *http.Request) {
 		return
 
 	}
+	if rt.RotatedAt.Valid {
+		cfg.revokeRefreshTokenFamily(r, rt)
+		_ = respondWithError(w, http.StatusUnauthorized, "refresh token has already been used")
+		return
+	}
 	if rt.RevokedAt.Valid {
+		cfg.metrics.refreshes.WithLabelValues("revoked").Inc()
 		_ = respondWithError(w, http.StatusUnauthorized, "refresh token has been revoked")
 		return
 	}
 	if rt.ExpiresAt.Before(time.Now().UTC()) {
+		cfg.metrics.refreshes.WithLabelValues("expired").Inc()
 		_ = respondWithError(w, http.StatusUnauthorized, "refresh token expired")
 		return
 	}
-	accessToken, err := auth.MakeJWT(rt.UserID, cfg.secret)
+	// The role may have changed since the last token, it is read again.
+	user, err := cfg.db.GetUserByID(r.Context(), rt.UserID)
+	if err != nil {
+		log.Printf("failed to get user by id: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	if userSuspended(user) {
+		_ = respondWithError(w, http.StatusForbidden, suspensionMessage(user))
+		return
+	}
+	if _, err := cfg.db.RotateRefreshToken(r.Context(), rt.Token); err != nil {
+		if err == sql.ErrNoRows {
+			// Another request rotated or revoked it in the meantime.
+			cfg.revokeRefreshTokenFamily(r, rt)
+			_ = respondWithError(w, http.StatusUnauthorized, "refresh token has already been used")
+		} else {
+			log.Printf("failed to rotate refresh token: %v", err)
+			_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		}
+		return
+	}
+	newRefreshToken, err := cfg.issueRefreshToken(r.Context(), rt.UserID, rt.FamilyID)
+	if err != nil {
+		log.Printf("failed to create refresh token: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	err = cfg.db.TouchSession(r.Context(), database.TouchSessionParams{
+		ID:        rt.FamilyID,
+		UserAgent: r.UserAgent(),
+		IpAddress: clientIP(r),
+	})
+	if err != nil {
+		log.Printf("failed to update session: %v", err)
+	}
+	accessToken, err := auth.MakeJWT(user.ID, auth.Role(user.Role), cfg.secret, cfg.accessTokenTTL)
 	if err != nil {
 		log.Printf("failed to create access token: %v", err)
 		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
 		return
 	}
+	cfg.metrics.refreshes.WithLabelValues("success").Inc()
 	_ = respondWithJSON(w, http.StatusOK, returnVals{
-		Token: accessToken,
+		Token:        accessToken,
+		RefreshToken: newRefreshToken,
+	})
+}
+
+func (cfg *apiConfig) revokeRefreshTokenFamily(r *http.Request, rt database.RefreshToken) {
+	log.Printf("refresh token reuse detected for user %s, revoking family %s", rt.UserID, rt.FamilyID)
+	cfg.metrics.refreshes.WithLabelValues("reuse_detected").Inc()
+	if err := cfg.db.RevokeRefreshTokenFamily(r.Context(), rt.FamilyID); err != nil {
+		log.Printf("failed to revoke refresh token family: %v", err)
+	}
+}
+
+func (cfg *apiConfig) issueRefreshToken(ctx context.Context, userID, familyID uuid.UUID) (string, error) {
+	token, err := auth.MakeRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	_, err = cfg.db.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{
+		Token:     token,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		ExpiresAt: time.Now().Add(cfg.refreshTokenTTL).UTC(),
+		RevokedAt: sql.NullTime{},
+		UserID:    userID,
+		FamilyID:  familyID,
 	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
 }
 
 func (cfg *apiConfig) handlerRevoke(w http.ResponseWriter, r *http.Request) {
@@ -331,14 +440,8 @@ func (cfg *apiConfig) handlerRevoke(w http.ResponseWriter, r *http.Request) {
 func (cfg *apiConfig) handlerCreateChirp(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 	type reqParams struct {
-		Body string `json:"body"`
-	}
-	type returnVals struct {
-		ID        uuid.UUID `json:"id"`
-		CreatedAt time.Time `json:"created_at"`
-		UpdatedAt time.Time `json:"updated_at"`
-		Body      string    `json:"body"`
-		UserID    uuid.UUID `json:"user_id"`
+		Body      string     `json:"body"`
+		InReplyTo *uuid.UUID `json:"in_reply_to"`
 	}
 	decoder := json.NewDecoder(r.Body)
 	params := reqParams{}
@@ -346,83 +449,262 @@ func (cfg *apiConfig) handlerCreateChirp(w http.ResponseWriter, r *http.Request)
 		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
 		return
 	}
-	token, err := auth.GetBearerToken(r.Header)
+	user, _ := userFromContext(r.Context())
+	if msg := cfg.validateChirpBody(params.Body); msg != "" {
+		_ = respondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
+	checked := cfg.moderation.Check(params.Body)
+	if checked.Action == moderation.Reject {
+		_ = respondWithError(w, http.StatusBadRequest, rejectedChirpMessage)
+		return
+	}
+	parentID := uuid.NullUUID{}
+	if params.InReplyTo != nil {
+		parent, err := cfg.db.GetChirpByID(r.Context(), *params.InReplyTo)
+		if err == sql.ErrNoRows {
+			_ = respondWithError(w, http.StatusBadRequest, "in_reply_to chirp does not exist")
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to get chirp by id: %v\n", err)
+			_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+			return
+		}
+		if parent.RechirpOfID.Valid {
+			_ = respondWithError(w, http.StatusBadRequest, "cannot reply to a rechirp, reply to the original chirp instead")
+			return
+		}
+		blocked, err := cfg.db.IsBlocked(r.Context(), database.IsBlockedParams{
+			BlockerID: parent.UserID,
+			BlockedID: user.ID,
+		})
+		if err != nil {
+			log.Printf("failed to check for a block: %v", err)
+			_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+			return
+		}
+		if blocked {
+			_ = respondWithError(w, http.StatusForbidden, "cannot reply to a user who blocked you")
+			return
+		}
+		parentID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+	}
+	chirp, err := cfg.db.CreateChirp(r.Context(), database.CreateChirpParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		Body:      checked.Text,
+		UserID:    user.ID,
+		ParentID:  parentID,
+	})
+	if err != nil {
+		log.Printf("failed to create chirp: %v\n", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	cfg.metrics.chirpsCreated.Inc()
+	cfg.indexChirp(r.Context(), chirp)
+	cfg.flagChirp(r.Context(), chirp, checked)
+	data, err := cfg.chirpJSON(r.Context(), chirp)
+	if err != nil {
+		log.Printf("failed to load chirp details: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	_ = respondWithJSON(w, http.StatusCreated, data)
+}
+
+// handlerRechirp reposts a chirp as is. Rechirping a rechirp reposts the
+// original chirp, and rechirping a chirp again returns the first rechirp.
+func (cfg *apiConfig) handlerRechirp(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	user, _ := userFromContext(r.Context())
+	original, ok := cfg.repostTarget(w, r)
+	if !ok {
+		return
+	}
+	chirp, err := cfg.db.CreateRechirp(r.Context(), database.CreateRechirpParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		ChirpID:   original.ID,
+	})
+	if err != nil {
+		log.Printf("failed to create rechirp: %v\n", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	data, err := cfg.chirpJSON(r.Context(), chirp)
+	if err != nil {
+		log.Printf("failed to load chirp details: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	_ = respondWithJSON(w, http.StatusCreated, data)
+}
+
+func (cfg *apiConfig) handlerUndoRechirp(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	user, _ := userFromContext(r.Context())
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
 	if err != nil {
-		_ = respondWithError(w, http.StatusUnauthorized, "token is missing")
+		_ = respondWithError(w, http.StatusBadRequest, "Chirp id is not valid")
 		return
 	}
-	userID, err := auth.ValidateJWT(token, cfg.secret)
+	err = cfg.db.DeleteRechirp(r.Context(), database.DeleteRechirpParams{
+		UserID:  user.ID,
+		ChirpID: chirpID,
+	})
 	if err != nil {
-		_ = respondWithError(w, http.StatusUnauthorized, "token is invalid or expired")
+		log.Printf("failed to delete rechirp: %v\n", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerQuoteChirp posts a chirp with its own body that embeds the chirp it
+// quotes.
+func (cfg *apiConfig) handlerQuoteChirp(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	type reqParams struct {
+		Body string `json:"body"`
+	}
+	user, _ := userFromContext(r.Context())
+	decoder := json.NewDecoder(r.Body)
+	params := reqParams{}
+	if err := decoder.Decode(&params); err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, "request body is not valid JSON")
+		return
+	}
+	if msg := cfg.validateChirpBody(params.Body); msg != "" {
+		_ = respondWithError(w, http.StatusBadRequest, msg)
 		return
 	}
-	if len(params.Body) > 140 {
-		_ = respondWithError(w, http.StatusBadRequest, "Chirp is too long")
+	checked := cfg.moderation.Check(params.Body)
+	if checked.Action == moderation.Reject {
+		_ = respondWithError(w, http.StatusBadRequest, rejectedChirpMessage)
 		return
 	}
-	if strings.TrimSpace(params.Body) == "" {
-		_ = respondWithError(w, http.StatusBadRequest, "Body is required and must not be empty")
+	original, ok := cfg.repostTarget(w, r)
+	if !ok {
 		return
 	}
 	chirp, err := cfg.db.CreateChirp(r.Context(), database.CreateChirpParams{
 		ID:        uuid.New(),
 		CreatedAt: time.Now().UTC(),
 		UpdatedAt: time.Now().UTC(),
-		Body:      censorBadWords(params.Body),
-		UserID:    userID,
+		Body:      checked.Text,
+		UserID:    user.ID,
+		QuoteOfID: uuid.NullUUID{UUID: original.ID, Valid: true},
 	})
 	if err != nil {
 		log.Printf("failed to create chirp: %v\n", err)
 		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
 		return
 	}
-	_ = respondWithJSON(w, http.StatusCreated, returnVals{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserID:    chirp.UserID,
-	})
+	cfg.metrics.chirpsCreated.Inc()
+	cfg.indexChirp(r.Context(), chirp)
+	cfg.flagChirp(r.Context(), chirp, checked)
+	data, err := cfg.chirpJSON(r.Context(), chirp)
+	if err != nil {
+		log.Printf("failed to load chirp details: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	_ = respondWithJSON(w, http.StatusCreated, data)
+}
+
+// repostTarget loads the chirp of the path to rechirp or quote. A rechirp
+// stands for the chirp it reposts, so that chirp is returned instead. It
+// writes the error response itself when there is none.
+func (cfg *apiConfig) repostTarget(w http.ResponseWriter, r *http.Request) (database.Chirp, bool) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, "Chirp id is not valid")
+		return database.Chirp{}, false
+	}
+	chirp, err := cfg.db.GetChirpByID(r.Context(), chirpID)
+	if err == nil && chirp.RechirpOfID.Valid {
+		chirp, err = cfg.db.GetChirpByID(r.Context(), chirp.RechirpOfID.UUID)
+	}
+	if err == sql.ErrNoRows {
+		_ = respondWithError(w, http.StatusNotFound, "the chirp does not exist")
+		return database.Chirp{}, false
+	}
+	if err != nil {
+		log.Printf("Failed to get chirp by id: %v\n", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return database.Chirp{}, false
+	}
+	return chirp, true
 }
 
 func (cfg *apiConfig) handlerGetChirps(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
-	type returnVals struct {
-		ID        uuid.UUID `json:"id"`
-		CreatedAt time.Time `json:"created_at"`
-		UpdatedAt time.Time `json:"updated_at"`
-		Body      string    `json:"body"`
-		UserID    uuid.UUID `json:"user_id"`
+	page, err := parsePageParams(r)
+	if err != nil {
+		_ = respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	authorID := uuid.NullUUID{}
+	if s := r.URL.Query().Get("author_id"); s != "" {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			_ = respondWithError(w, http.StatusBadRequest, "author_id is not valid")
+			return
+		}
+		authorID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+	sort := r.URL.Query().Get("sort")
+	if sort != "" && sort != "asc" && sort != "desc" {
+		_ = respondWithError(w, http.StatusBadRequest, "sort must be asc or desc")
+		return
+	}
+	// The chirps of the users the caller muted are left out, unless asked for
+	// with author_id.
+	viewer := viewerID(r.Context())
+	cursorCreatedAt, cursorID := page.cursorArgs()
+	var chirps []database.Chirp
+	if sort == "desc" {
+		chirps, err = cfg.db.GetChirpsDesc(r.Context(), database.GetChirpsDescParams{
+			AuthorID:        authorID,
+			BeforeCreatedAt: cursorCreatedAt,
+			BeforeID:        cursorID,
+			ViewerID:        viewer,
+			PageLimit:       page.queryLimit(),
+		})
+	} else {
+		chirps, err = cfg.db.GetChirps(r.Context(), database.GetChirpsParams{
+			AuthorID:       authorID,
+			AfterCreatedAt: cursorCreatedAt,
+			AfterID:        cursorID,
+			ViewerID:       viewer,
+			PageLimit:      page.queryLimit(),
+		})
 	}
-	chirps, err := cfg.db.GetChirps(r.Context())
 	if err != nil {
 		log.Printf("failed to get all chirps from db: %v\n", err)
 		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
 		return
 	}
-	var data []returnVals
-	for _, c := range chirps {
-		data = append(data, returnVals{
-			ID:        c.ID,
-			CreatedAt: c.CreatedAt,
-			UpdatedAt: c.UpdatedAt,
-			Body:      c.Body,
-			UserID:    c.UserID,
-		})
+	if len(chirps) > page.Limit {
+		chirps = chirps[:page.Limit]
+		last := chirps[len(chirps)-1]
+		setNextLink(w, r, pageCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+	data, err := cfg.chirpsJSON(r.Context(), chirps)
+	if err != nil {
+		log.Printf("failed to load chirp details: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
 	}
-
 	_ = respondWithJSON(w, http.StatusOK, data)
 }
 
 func (cfg *apiConfig) handlerGetChirpByID(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
-	type returnVals struct {
-		ID        uuid.UUID `json:"id"`
-		CreatedAt time.Time `json:"created_at"`
-		UpdatedAt time.Time `json:"updated_at"`
-		Body      string    `json:"body"`
-		UserID    uuid.UUID `json:"user_id"`
-	}
 	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
 	if err != nil {
 		_ = respondWithError(w, http.StatusBadRequest, "Chirp id is not valid")
@@ -438,13 +720,13 @@ func (cfg *apiConfig) handlerGetChirpByID(w http.ResponseWriter, r *http.Request
 		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
 		return
 	}
-	_ = respondWithJSON(w, http.StatusOK, returnVals{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserID:    chirp.UserID,
-	})
+	data, err := cfg.chirpJSON(r.Context(), chirp)
+	if err != nil {
+		log.Printf("failed to load chirp details: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	_ = respondWithJSON(w, http.StatusOK, data)
 }
 
 func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, r *http.Request) {
@@ -463,21 +745,12 @@ func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, r *http.Request)
 		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
 		return
 	}
-	accessToken, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		_ = respondWithError(w, http.StatusUnauthorized, "access token is missing/malformed in the header")
-		return
-	}
-	userID, err := auth.ValidateJWT(accessToken, cfg.secret)
-	if err != nil {
-		_ = respondWithError(w, http.StatusUnauthorized, "access token is invalid")
-		return
-	}
-	if chirp.UserID != userID {
+	user, _ := userFromContext(r.Context())
+	if chirp.UserID != user.ID {
 		_ = respondWithError(w, http.StatusForbidden, "cannot delete chirps of other users")
 		return
 	}
-	err = cfg.db.DeleteChirpByID(r.Context(), chirpID)
+	err = deleteChirp(r.Context(), cfg.db, chirpID)
 	if err != nil {
 		log.Printf("Failed to delete chirp: %v\n", err)
 		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
@@ -490,10 +763,14 @@ func (cfg *apiConfig) handlerPolkaWebhooks(w http.ResponseWriter, r *http.Reques
 	defer r.Body.Close()
 
 	apiKey, err := auth.GetAPIKey(r.Header)
-	if err != nil || apiKey != cfg.polkaKey {
+	if err != nil {
 		_ = respondWithError(w, http.StatusUnauthorized, err.Error())
 		return
 	}
+	if apiKey != cfg.polkaKey {
+		_ = respondWithError(w, http.StatusUnauthorized, "api key is invalid")
+		return
+	}
 
 	type reqParams struct {
 		Event string `json:"event"`
@@ -508,6 +785,7 @@ func (cfg *apiConfig) handlerPolkaWebhooks(w http.ResponseWriter, r *http.Reques
 		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
 		return
 	}
+	cfg.metrics.webhookEvents.WithLabelValues(webhookEventLabel(params.Event)).Inc()
 	if params.Event != "user.upgraded" {
 		w.WriteHeader(http.StatusNoContent)
 		return
@@ -515,6 +793,7 @@ func (cfg *apiConfig) handlerPolkaWebhooks(w http.ResponseWriter, r *http.Reques
 	userId, err := uuid.Parse(params.Data.UserId)
 	if err != nil {
 		_ = respondWithError(w, http.StatusBadRequest, "user id is invalid")
+		return
 	}
 	_, err = cfg.db.UpgradeUser(r.Context(), userId)
 	if err != nil {
@@ -544,37 +823,21 @@ func respondWithError(w http.ResponseWriter, statusCode int, msg string) error {
 	return respondWithJSON(w, statusCode, map[string]string{"error": msg})
 }
 
-func censorBadWords(s string) string {
-	words := strings.Split(s, " ")
-	badWords := []string{"kerfuffle", "sharbert", "fornax"}
-	censoredWords := []string{}
-	for _, w := range words {
-		if slices.Contains(badWords, strings.ToLower(w)) {
-			w = "****"
-		}
-		censoredWords = append(censoredWords, w)
+// validateChirpBody returns the error message for a body that cannot be
+// posted, or an empty string.
+func (cfg *apiConfig) validateChirpBody(body string) string {
+	if len(body) > cfg.maxChirpLength {
+		return "Chirp is too long"
 	}
-	return strings.Join(censoredWords, " ")
+	if strings.TrimSpace(body) == "" {
+		return "Body is required and must not be empty"
+	}
+	return ""
 }
 
-func main() {
-	godotenv.Load()
-	apiCfg := &apiConfig{}
+func (cfg *apiConfig) routes() http.Handler {
 	mux := http.NewServeMux()
-	dbURL := os.Getenv("DB_URL")
-	db, err := sql.Open("postgres", dbURL)
-	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
-	}
-	if err := db.Ping(); err != nil {
-		log.Fatalf("database not responding: %v", err)
-	}
-	apiCfg.platform = os.Getenv("PLATFORM")
-	apiCfg.secret = os.Getenv("SECRET_KEY")
-	apiCfg.polkaKey = os.Getenv("POLKA_KEY")
-	apiCfg.db = database.New(db)
-
-	fs := apiCfg.middlewareMetricsInc(http.FileServer(http.Dir(".")))
+	fs := cfg.middlewareMetricsInc(http.FileServer(http.Dir(".")))
 	mux.Handle("/app/", http.StripPrefix("/app", fs))
 
 	mux.HandleFunc("GET /api/healthz", func(w http.ResponseWriter, r *http.Request) {
@@ -582,27 +845,134 @@ func main() {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
 	})
-	mux.HandleFunc("GET /api/chirps", apiCfg.handlerGetChirps)
-	mux.HandleFunc("GET /api/chirps/{chirpID}", apiCfg.handlerGetChirpByID)
-	mux.HandleFunc("POST /api/users", apiCfg.handlerCreateUser)
-	mux.HandleFunc("POST /api/chirps", apiCfg.handlerCreateChirp)
-	mux.HandleFunc("POST /api/login", apiCfg.handlerLogin)
-	mux.HandleFunc("POST /api/refresh", apiCfg.handlerRefresh)
-	mux.HandleFunc("POST /api/revoke", apiCfg.handlerRevoke)
-	mux.HandleFunc("POST /api/polka/webhooks", apiCfg.handlerPolkaWebhooks)
-	mux.HandleFunc("PUT /api/users", apiCfg.handlerUpdateUser)
-	mux.HandleFunc("DELETE /api/chirps/{chirpID}", apiCfg.handlerDeleteChirp)
-
-	mux.HandleFunc("GET /admin/metrics", apiCfg.handlerMetrics)
-	mux.HandleFunc("POST /admin/reset", apiCfg.handlerReset)
+	mux.HandleFunc("GET /api/chirps", cfg.middlewareOptionalAuth(cfg.handlerGetChirps))
+	mux.HandleFunc("GET /api/chirps/{chirpID}", cfg.middlewareOptionalAuth(cfg.handlerGetChirpByID))
+	mux.HandleFunc("POST /api/users", cfg.handlerCreateUser)
+	mux.HandleFunc("POST /api/chirps", cfg.middlewareAuth(cfg.handlerCreateChirp))
+	mux.HandleFunc("POST /api/chirps/{chirpID}/rechirp", cfg.middlewareAuth(cfg.handlerRechirp))
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/rechirp", cfg.middlewareAuth(cfg.handlerUndoRechirp))
+	mux.HandleFunc("POST /api/chirps/{chirpID}/quote", cfg.middlewareAuth(cfg.handlerQuoteChirp))
+	mux.HandleFunc("POST /api/login", cfg.handlerLogin)
+	mux.HandleFunc("POST /api/refresh", cfg.handlerRefresh)
+	mux.HandleFunc("POST /api/revoke", cfg.handlerRevoke)
+	mux.HandleFunc("POST /api/polka/webhooks", cfg.handlerPolkaWebhooks)
+	mux.HandleFunc("PUT /api/users", cfg.middlewareAuth(cfg.handlerUpdateUser))
+	mux.HandleFunc("PUT /api/chirps/{chirpID}", cfg.middlewareAuth(cfg.handlerUpdateChirp))
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}", cfg.middlewareAuth(cfg.handlerDeleteChirp))
+	mux.HandleFunc("GET /api/chirps/{chirpID}/revisions", cfg.handlerGetChirpRevisions)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/thread", cfg.middlewareOptionalAuth(cfg.handlerGetChirpThread))
+	mux.HandleFunc("POST /api/chirps/{chirpID}/like", cfg.middlewareAuth(cfg.handlerLikeChirp))
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/like", cfg.middlewareAuth(cfg.handlerUnlikeChirp))
+	mux.HandleFunc("GET /api/chirps/{chirpID}/likes", cfg.handlerGetChirpLikes)
+	mux.HandleFunc("POST /api/users/{userID}/follow", cfg.middlewareAuth(cfg.handlerFollowUser))
+	mux.HandleFunc("DELETE /api/users/{userID}/follow", cfg.middlewareAuth(cfg.handlerUnfollowUser))
+	mux.HandleFunc("POST /api/users/{userID}/block", cfg.middlewareAuth(cfg.handlerBlockUser))
+	mux.HandleFunc("DELETE /api/users/{userID}/block", cfg.middlewareAuth(cfg.handlerUnblockUser))
+	mux.HandleFunc("POST /api/users/{userID}/mute", cfg.middlewareAuth(cfg.handlerMuteUser))
+	mux.HandleFunc("DELETE /api/users/{userID}/mute", cfg.middlewareAuth(cfg.handlerUnmuteUser))
+	mux.HandleFunc("GET /api/users/{userID}/followers", cfg.handlerGetFollowers)
+	mux.HandleFunc("GET /api/users/{userID}/following", cfg.handlerGetFollowing)
+	mux.HandleFunc("GET /api/users/me/mentions", cfg.middlewareAuth(cfg.handlerGetMyMentions))
+	mux.HandleFunc("GET /api/users/{userID}/likes", cfg.middlewareOptionalAuth(cfg.handlerGetUserLikes))
+	mux.HandleFunc("GET /api/timeline", cfg.middlewareAuth(cfg.handlerGetTimeline))
+	mux.HandleFunc("GET /api/hashtags/trending", cfg.handlerGetTrendingHashtags)
+	mux.HandleFunc("GET /api/hashtags/{tag}/chirps", cfg.middlewareOptionalAuth(cfg.handlerGetHashtagChirps))
+	mux.HandleFunc("GET /api/search", cfg.middlewareOptionalAuth(cfg.handlerSearch))
+	mux.HandleFunc("POST /api/chirps/{chirpID}/report", cfg.middlewareAuth(cfg.handlerReportChirp))
+	mux.HandleFunc("POST /api/users/{userID}/report", cfg.middlewareAuth(cfg.handlerReportUser))
+	mux.HandleFunc("GET /api/sessions", cfg.middlewareAuth(cfg.handlerGetSessions))
+	mux.HandleFunc("DELETE /api/sessions", cfg.middlewareAuth(cfg.handlerDeleteSessions))
+	mux.HandleFunc("DELETE /api/sessions/{sessionID}", cfg.middlewareAuth(cfg.handlerDeleteSession))
+
+	mux.Handle("GET /metrics", cfg.metrics.handler())
+	mux.HandleFunc("GET /admin/metrics", cfg.middlewareRole(auth.RoleAdmin, cfg.handlerMetrics))
+	mux.HandleFunc("POST /admin/reset", cfg.middlewareRole(auth.RoleAdmin, cfg.handlerReset))
+	mux.HandleFunc("PUT /admin/users/{userID}/role", cfg.middlewareRole(auth.RoleAdmin, cfg.handlerSetUserRole))
+	mux.HandleFunc("PUT /admin/users/{userID}/suspension", cfg.middlewareRole(auth.RoleModerator, cfg.handlerSuspendUser))
+	mux.HandleFunc("DELETE /admin/users/{userID}/suspension", cfg.middlewareRole(auth.RoleModerator, cfg.handlerLiftSuspension))
+	mux.HandleFunc("GET /admin/moderation/rules", cfg.middlewareRole(auth.RoleAdmin, cfg.handlerGetModerationRules))
+	mux.HandleFunc("PUT /admin/moderation/rules/{word}", cfg.middlewareRole(auth.RoleAdmin, cfg.handlerPutModerationRule))
+	mux.HandleFunc("DELETE /admin/moderation/rules/{word}", cfg.middlewareRole(auth.RoleAdmin, cfg.handlerDeleteModerationRule))
+	mux.HandleFunc("POST /admin/moderation/reload", cfg.middlewareRole(auth.RoleAdmin, cfg.handlerReloadModerationRules))
+	mux.HandleFunc("GET /admin/moderation/flags", cfg.middlewareRole(auth.RoleModerator, cfg.handlerGetFlaggedChirps))
+	mux.HandleFunc("GET /admin/reports", cfg.middlewareRole(auth.RoleModerator, cfg.handlerGetReports))
+	mux.HandleFunc("POST /admin/reports/{reportID}/claim", cfg.middlewareRole(auth.RoleModerator, cfg.handlerClaimReport))
+	mux.HandleFunc("POST /admin/reports/{reportID}/resolve", cfg.middlewareRole(auth.RoleModerator, cfg.handlerResolveReport))
+
+	return cfg.middlewareRequestID(
+		cfg.middlewareLogging(mux,
+			cfg.middlewareMetrics(mux,
+				cfg.middlewareRecover(mux))))
+}
+
+func main() {
+	godotenv.Load()
+	slog.SetDefault(newLogger(os.Stdout))
+	err := runCLI(context.Background(), os.Args[1:], os.LookupEnv, os.Stdin, os.Stdout)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// runServe implements the serve command.
+func runServe(ctx context.Context, c *cli, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected argument %q", args[0])
+	}
+	cfg, db := c.cfg, c.db
+	if cfg.DB.MigrateOnStart {
+		provider, err := newMigrationProvider(db)
+		if err != nil {
+			return fmt.Errorf("failed to load migrations: %w", err)
+		}
+		results, err := provider.Up(ctx)
+		for _, r := range results {
+			log.Printf("migration %s", r)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to migrate the database: %w", err)
+		}
+	}
+
+	apiCfg := &apiConfig{
+		platform:        cfg.Platform,
+		secret:          cfg.SecretKey,
+		polkaKey:        cfg.PolkaKey,
+		accessTokenTTL:  cfg.Auth.AccessTokenTTL,
+		refreshTokenTTL: cfg.Auth.RefreshTokenTTL,
+		maxChirpLength:  cfg.Chirps.MaxLength,
+		chirpEditWindow: cfg.Chirps.EditWindow,
+		trendingWindow:  cfg.Chirps.TrendingWindow,
+		moderation:      moderation.NewFilter(nil),
+		logger:          slog.Default(),
+		metrics:         newMetrics(),
+	}
+	apiCfg.db = database.New(instrumentedDB{db: db, duration: apiCfg.metrics.dbQueryDuration})
+	apiCfg.moderationWordsFile = cfg.Moderation.WordsFile
+	if err := apiCfg.loadModerationRules(ctx); err != nil {
+		return err
+	}
+	if cfg.Moderation.ReloadInterval > 0 {
+		go apiCfg.reloadModerationRules(ctx, cfg.Moderation.ReloadInterval)
+	}
 
 	server := &http.Server{
-		Addr:    ADDR + ":" + PORT,
-		Handler: mux,
+		Addr:              cfg.Addr,
+		Handler:           apiCfg.routes(),
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
 	}
 
-	log.Println("Server running at http://localhost:8080")
-	if err := server.ListenAndServe(); err != nil {
-		log.Fatal(err)
+	log.Printf("Server running at http://%s", cfg.Addr)
+	if err := serveUntilSignal(server, cfg.Server.ShutdownTimeout); err != nil {
+		return err
 	}
+	log.Println("Server stopped")
+	return nil
 }
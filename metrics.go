@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Specialized101/chirpy/internal/database"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metrics holds every collector of the server. They are all registered in
+// registry, which backs both /metrics and the /admin/metrics dashboard.
+type metrics struct {
+	registry        *prometheus.Registry
+	fileserverHits  prometheus.Gauge
+	httpRequests    *prometheus.CounterVec
+	httpDuration    *prometheus.HistogramVec
+	dbQueryDuration *prometheus.HistogramVec
+	logins          *prometheus.CounterVec
+	refreshes       *prometheus.CounterVec
+	chirpsCreated   prometheus.Counter
+	webhookEvents   *prometheus.CounterVec
+}
+
+func newMetrics() *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		fileserverHits: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "chirpy_fileserver_hits",
+			Help: "Requests served by the /app/ file server since the last reset.",
+		}),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "chirpy_http_requests_total",
+			Help: "HTTP requests by method, route pattern and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "chirpy_http_request_duration_seconds",
+			Help:    "HTTP request latency by method and route pattern.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		dbQueryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "chirpy_db_query_duration_seconds",
+			Help:    "Database query latency by sqlc query name.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"query"}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "chirpy_logins_total",
+			Help: "Login attempts by result.",
+		}, []string{"result"}),
+		refreshes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "chirpy_token_refreshes_total",
+			Help: "Refresh token exchanges by result.",
+		}, []string{"result"}),
+		chirpsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "chirpy_chirps_created_total",
+			Help: "Chirps created.",
+		}),
+		webhookEvents: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "chirpy_webhook_events_total",
+			Help: "Polka webhook events received by event type.",
+		}, []string{"event"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.fileserverHits,
+		m.httpRequests,
+		m.httpDuration,
+		m.dbQueryDuration,
+		m.logins,
+		m.refreshes,
+		m.chirpsCreated,
+		m.webhookEvents,
+	)
+	return m
+}
+
+func (m *metrics) handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// gaugeValue reads the current value of a gauge back from the registry.
+func (m *metrics) gaugeValue(name string) float64 {
+	families, err := m.registry.Gather()
+	if err != nil {
+		return 0
+	}
+	for _, f := range families {
+		if f.GetName() == name && len(f.GetMetric()) > 0 {
+			return f.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+	return 0
+}
+
+// webhookEventLabel keeps the label values of webhookEvents bounded, the
+// event name comes straight from the request body.
+func webhookEventLabel(event string) string {
+	if event == "user.upgraded" {
+		return event
+	}
+	return "other"
+}
+
+// middlewareMetrics records the count and latency of every request. mux is
+// only used to look up the pattern of the matched route.
+func (cfg *apiConfig) middlewareMetrics(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+
+		_, route := mux.Handler(r)
+		if route == "" {
+			route = "unmatched"
+		}
+		cfg.metrics.httpRequests.WithLabelValues(r.Method, route, strconv.Itoa(rec.status)).Inc()
+		cfg.metrics.httpDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// instrumentedDB times every query sent through it. sqlc prefixes each query
+// with a "-- name: QueryName :kind" comment, which is used as the label.
+type instrumentedDB struct {
+	db       database.DBTX
+	duration *prometheus.HistogramVec
+}
+
+func (idb instrumentedDB) observe(query string, start time.Time) {
+	idb.duration.WithLabelValues(queryName(query)).Observe(time.Since(start).Seconds())
+}
+
+func (idb instrumentedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	defer idb.observe(query, time.Now())
+	return idb.db.ExecContext(ctx, query, args...)
+}
+
+func (idb instrumentedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return idb.db.PrepareContext(ctx, query)
+}
+
+func (idb instrumentedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	defer idb.observe(query, time.Now())
+	return idb.db.QueryContext(ctx, query, args...)
+}
+
+func (idb instrumentedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	defer idb.observe(query, time.Now())
+	return idb.db.QueryRowContext(ctx, query, args...)
+}
+
+func queryName(query string) string {
+	rest, ok := strings.CutPrefix(query, "-- name: ")
+	if !ok {
+		return "unknown"
+	}
+	name, _, _ := strings.Cut(rest, " ")
+	return name
+}
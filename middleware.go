@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/Specialized101/chirpy/internal/auth"
+	"github.com/Specialized101/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+type contextKey int
+
+const (
+	authUserKey contextKey = iota
+	requestInfoKey
+)
+
+// authUser is the caller identified by the access token of the request.
+type authUser struct {
+	ID   uuid.UUID
+	Role auth.Role
+}
+
+func userFromContext(ctx context.Context) (authUser, bool) {
+	user, ok := ctx.Value(authUserKey).(authUser)
+	return user, ok
+}
+
+// viewerID is the caller as a query argument, NULL for an anonymous caller.
+// The listings use it to leave out the chirps of the users the caller muted.
+func viewerID(ctx context.Context) uuid.NullUUID {
+	user, ok := userFromContext(ctx)
+	return uuid.NullUUID{UUID: user.ID, Valid: ok}
+}
+
+// middlewareAuth rejects requests without a valid access token. Handlers
+// wrapped by it can read the caller with userFromContext.
+func (cfg *apiConfig) middlewareAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accessToken, err := auth.GetBearerToken(r.Header)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="chirpy"`)
+			_ = respondWithError(w, http.StatusUnauthorized, "access token is missing/malformed in the header")
+			return
+		}
+		userID, role, err := auth.ValidateJWT(accessToken, cfg.secret)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="chirpy", error="invalid_token"`)
+			_ = respondWithError(w, http.StatusUnauthorized, "access token is invalid")
+			return
+		}
+		if info := requestInfoFromContext(r.Context()); info != nil {
+			info.UserID = userID
+		}
+		// Access tokens outlive a suspension, a new role or the user, so
+		// writes check the user again. Reads need no account in good
+		// standing.
+		if !readOnly(r) {
+			user, ok := cfg.lookupCaller(w, r, userID)
+			if !ok {
+				return
+			}
+			if userSuspended(user) {
+				_ = respondWithError(w, http.StatusForbidden, suspensionMessage(user))
+				return
+			}
+			role = auth.Role(user.Role)
+		}
+		ctx := context.WithValue(r.Context(), authUserKey, authUser{ID: userID, Role: role})
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// middlewareOptionalAuth lets anonymous requests through, for routes that
+// only show more to a signed in caller. A token that is sent must be valid.
+func (cfg *apiConfig) middlewareOptionalAuth(next http.HandlerFunc) http.HandlerFunc {
+	auth := cfg.middlewareAuth(next)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			next(w, r)
+			return
+		}
+		auth(w, r)
+	}
+}
+
+// middlewareRole is middlewareAuth for the callers whose role includes role.
+// The role is read from the database rather than the access token, so a role
+// taken away applies to the tokens issued before.
+func (cfg *apiConfig) middlewareRole(role auth.Role, next http.HandlerFunc) http.HandlerFunc {
+	return cfg.middlewareAuth(func(w http.ResponseWriter, r *http.Request) {
+		caller, _ := userFromContext(r.Context())
+		// middlewareAuth looked the user up for writes already.
+		if readOnly(r) {
+			user, ok := cfg.lookupCaller(w, r, caller.ID)
+			if !ok {
+				return
+			}
+			caller.Role = auth.Role(user.Role)
+		}
+		if !caller.Role.Includes(role) {
+			_ = respondWithError(w, http.StatusForbidden, fmt.Sprintf("the %s role is required", role))
+			return
+		}
+		ctx := context.WithValue(r.Context(), authUserKey, caller)
+		next(w, r.WithContext(ctx))
+	})
+}
+
+func readOnly(r *http.Request) bool {
+	return r.Method == http.MethodGet || r.Method == http.MethodHead
+}
+
+// lookupCaller loads the user of a valid access token, which may have been
+// deleted since the token was issued. It responds itself when it fails.
+func (cfg *apiConfig) lookupCaller(w http.ResponseWriter, r *http.Request, userID uuid.UUID) (database.User, bool) {
+	user, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err == sql.ErrNoRows {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="chirpy", error="invalid_token"`)
+		_ = respondWithError(w, http.StatusUnauthorized, "the user of the access token does not exist")
+		return database.User{}, false
+	}
+	if err != nil {
+		log.Printf("failed to get user by id: %v", err)
+		_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		return database.User{}, false
+	}
+	return user, true
+}
+
+// middlewareRequestID propagates the X-Request-ID header of the request, or
+// generates one, and echoes it in the response.
+func (cfg *apiConfig) middlewareRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set("X-Request-ID", id)
+		ctx := context.WithValue(r.Context(), requestInfoKey, &requestInfo{ID: id})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// middlewareLogging writes one access log line per request. mux is only used
+// to look up the pattern of the matched route.
+func (cfg *apiConfig) middlewareLogging(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+
+		_, pattern := mux.Handler(r)
+		attrs := []slog.Attr{
+			slog.String("method", r.Method),
+			slog.String("route", pattern),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.status),
+			slog.Int("bytes", rec.bytes),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("remote_ip", clientIP(r)),
+			slog.String("user_agent", r.UserAgent()),
+		}
+		if info := requestInfoFromContext(r.Context()); info != nil {
+			attrs = append(attrs, slog.String("request_id", info.ID))
+			if info.UserID != uuid.Nil {
+				attrs = append(attrs, slog.String("user_id", info.UserID.String()))
+			}
+		}
+		cfg.logger.LogAttrs(r.Context(), slog.LevelInfo, "request", attrs...)
+	})
+}
+
+// middlewareRecover turns a panicking handler into the standard JSON 500
+// response instead of a dropped connection.
+func (cfg *apiConfig) middlewareRecover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			err := recover()
+			if err == nil {
+				return
+			}
+			if err == http.ErrAbortHandler {
+				panic(err)
+			}
+			attrs := []any{
+				slog.Any("panic", err),
+				slog.String("stack", string(debug.Stack())),
+				slog.Any("headers", redactHeaders(r.Header)),
+			}
+			if info := requestInfoFromContext(r.Context()); info != nil {
+				attrs = append(attrs, slog.String("request_id", info.ID))
+			}
+			cfg.logger.ErrorContext(r.Context(), "panic while serving request", attrs...)
+			_ = respondWithError(w, http.StatusInternalServerError, "Something went wrong")
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
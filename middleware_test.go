@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Specialized101/chirpy/internal/auth"
+	"github.com/Specialized101/chirpy/internal/memstore"
+	"github.com/google/uuid"
+)
+
+func TestMiddlewareAuth(t *testing.T) {
+	cfg := &apiConfig{secret: "secret"}
+	userID := uuid.New()
+	validToken, err := auth.MakeJWT(userID, auth.RoleUser, cfg.secret, time.Hour)
+	if err != nil {
+		t.Fatalf("failed to create JWT: %v", err)
+	}
+	otherToken, err := auth.MakeJWT(userID, auth.RoleUser, "other secret", time.Hour)
+	if err != nil {
+		t.Fatalf("failed to create JWT: %v", err)
+	}
+
+	cases := []struct {
+		authorization string
+		expected      int
+	}{
+		{authorization: "", expected: http.StatusUnauthorized},
+		{authorization: "Bearer", expected: http.StatusUnauthorized},
+		{authorization: "Bearer " + otherToken, expected: http.StatusUnauthorized},
+		{authorization: "Bearer " + validToken, expected: http.StatusOK},
+	}
+
+	for _, c := range cases {
+		var received uuid.UUID
+		handler := cfg.middlewareAuth(func(w http.ResponseWriter, r *http.Request) {
+			user, _ := userFromContext(r.Context())
+			received = user.ID
+		})
+		r := httptest.NewRequest("GET", "/api/timeline", nil)
+		if c.authorization != "" {
+			r.Header.Set("Authorization", c.authorization)
+		}
+		w := httptest.NewRecorder()
+		handler(w, r)
+		if w.Code != c.expected {
+			t.Errorf("%q: expected status %d, received %d", c.authorization, c.expected, w.Code)
+			continue
+		}
+		if c.expected == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%q: WWW-Authenticate header is missing", c.authorization)
+		}
+		if c.expected == http.StatusOK && received != userID {
+			t.Errorf("%q: expected user %v, received %v", c.authorization, userID, received)
+		}
+	}
+}
+
+func TestMiddlewareAuthDeletedUser(t *testing.T) {
+	cfg := &apiConfig{secret: "secret", db: memstore.New()}
+	token, err := auth.MakeJWT(uuid.New(), auth.RoleUser, cfg.secret, time.Hour)
+	if err != nil {
+		t.Fatalf("failed to create JWT: %v", err)
+	}
+	handler := cfg.middlewareAuth(func(w http.ResponseWriter, r *http.Request) {})
+	for _, method := range []string{"GET", "POST"} {
+		r := httptest.NewRequest(method, "/api/chirps", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		handler(w, r)
+		// Reads do not look the user up, writes do.
+		expected := http.StatusOK
+		if method == "POST" {
+			expected = http.StatusUnauthorized
+		}
+		if w.Code != expected {
+			t.Errorf("%s: expected status %d, received %d", method, expected, w.Code)
+		}
+	}
+}
+
+func TestMiddlewareRequestID(t *testing.T) {
+	cfg := &apiConfig{}
+	var received string
+	handler := cfg.middlewareRequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = requestInfoFromContext(r.Context()).ID
+	}))
+
+	cases := []struct {
+		header    string
+		propagate bool
+	}{
+		{header: "", propagate: false},
+		{header: "abc-123", propagate: true},
+		{header: "bad id\n", propagate: false},
+		{header: strings.Repeat("a", 129), propagate: false},
+	}
+
+	for _, c := range cases {
+		r := httptest.NewRequest("GET", "/api/healthz", nil)
+		if c.header != "" {
+			r.Header.Set("X-Request-ID", c.header)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		actual := w.Header().Get("X-Request-ID")
+		if actual == "" || actual != received {
+			t.Errorf("%q: request id %q was not echoed, handler saw %q", c.header, actual, received)
+		}
+		if (actual == c.header) != c.propagate {
+			t.Errorf("%q: expected propagate=%v, received %q", c.header, c.propagate, actual)
+		}
+	}
+}
+
+func TestAccessLogRedactsAndRecovers(t *testing.T) {
+	var logs bytes.Buffer
+	cfg := &apiConfig{secret: "secret", logger: newLogger(&logs)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/panic/{id}", cfg.middlewareAuth(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	handler := cfg.middlewareRequestID(cfg.middlewareLogging(mux, cfg.middlewareRecover(mux)))
+
+	userID := uuid.New()
+	token, err := auth.MakeJWT(userID, auth.RoleUser, cfg.secret, time.Hour)
+	if err != nil {
+		t.Fatalf("failed to create JWT: %v", err)
+	}
+	r := httptest.NewRequest("GET", "/api/panic/1", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	r.Header.Set("X-Request-ID", "req-1")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), `"error"`) {
+		t.Errorf("expected a JSON 500, received %d: %s", w.Code, w.Body.String())
+	}
+	if strings.Contains(logs.String(), token) {
+		t.Errorf("access token leaked into the logs: %s", logs.String())
+	}
+
+	var entries []map[string]any
+	decoder := json.NewDecoder(&logs)
+	for decoder.More() {
+		entry := map[string]any{}
+		if err := decoder.Decode(&entry); err != nil {
+			t.Fatalf("log line is not JSON: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected a panic and an access log line, received %d lines", len(entries))
+	}
+	access := entries[1]
+	expected := map[string]any{
+		"msg":        "request",
+		"method":     "GET",
+		"route":      "GET /api/panic/{id}",
+		"status":     float64(http.StatusInternalServerError),
+		"request_id": "req-1",
+		"user_id":    userID.String(),
+	}
+	for k, v := range expected {
+		if access[k] != v {
+			t.Errorf("%s: expected %v, received %v", k, v, access[k])
+		}
+	}
+}
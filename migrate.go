@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Specialized101/chirpy/sql/schema"
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+)
+
+// newMigrationProvider runs the embedded migrations against db. The session
+// lock keeps several servers started with -migrate-on-start from migrating
+// at the same time.
+func newMigrationProvider(db *sql.DB) (*goose.Provider, error) {
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return nil, err
+	}
+	return goose.NewProvider(goose.DialectPostgres, db, schema.FS, goose.WithSessionLocker(locker))
+}
+
+// migrationJSON is the -json output of a migration that ran or of a line of
+// the status.
+type migrationJSON struct {
+	Version    int64      `json:"version"`
+	Source     string     `json:"source"`
+	Direction  string     `json:"direction,omitempty"`
+	DurationMs int64      `json:"duration_ms,omitempty"`
+	State      string     `json:"state,omitempty"`
+	AppliedAt  *time.Time `json:"applied_at,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// runMigrate implements the migrate command.
+func runMigrate(ctx context.Context, c *cli, args []string) error {
+	if err := exactArgs(args, 1, "migrate [flags] up|down|status|redo"); err != nil {
+		return err
+	}
+	provider, err := newMigrationProvider(c.db)
+	if err != nil {
+		return fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	switch args[0] {
+	case "up":
+		results, err := provider.Up(ctx)
+		if printErr := c.printMigrationResults(results); printErr != nil {
+			return printErr
+		}
+		return err
+	case "down":
+		result, err := provider.Down(ctx)
+		if errors.Is(err, goose.ErrNoNextVersion) {
+			return errors.New("no migration to roll back")
+		}
+		if err != nil {
+			return err
+		}
+		return c.printMigrationResults([]*goose.MigrationResult{result})
+	case "redo":
+		down, err := provider.Down(ctx)
+		if errors.Is(err, goose.ErrNoNextVersion) {
+			return errors.New("no migration to redo")
+		}
+		if err != nil {
+			return err
+		}
+		up, err := provider.ApplyVersion(ctx, down.Source.Version, true)
+		if printErr := c.printMigrationResults([]*goose.MigrationResult{down, up}); printErr != nil {
+			return printErr
+		}
+		return err
+	case "status":
+		statuses, err := provider.Status(ctx)
+		if err != nil {
+			return err
+		}
+		return c.printMigrationStatus(statuses)
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected up, down, status or redo", args[0])
+	}
+}
+
+func (c *cli) printMigrationResults(results []*goose.MigrationResult) error {
+	var text strings.Builder
+	data := []migrationJSON{}
+	for _, r := range results {
+		if r == nil {
+			continue
+		}
+		m := migrationJSON{
+			Version:    r.Source.Version,
+			Source:     path.Base(r.Source.Path),
+			Direction:  r.Direction,
+			DurationMs: r.Duration.Milliseconds(),
+		}
+		if r.Error != nil {
+			m.Error = r.Error.Error()
+			fmt.Fprintf(&text, "FAIL  %s %s: %v\n", r.Direction, m.Source, r.Error)
+		} else {
+			fmt.Fprintln(&text, r)
+		}
+		data = append(data, m)
+	}
+	if len(data) == 0 {
+		text.WriteString("no pending migrations\n")
+	}
+	return c.print(data, "%s", strings.TrimSuffix(text.String(), "\n"))
+}
+
+func (c *cli) printMigrationStatus(statuses []*goose.MigrationStatus) error {
+	var text strings.Builder
+	fmt.Fprintf(&text, "%-25s %s", "Applied At", "Migration")
+	data := []migrationJSON{}
+	for _, s := range statuses {
+		m := migrationJSON{
+			Version: s.Source.Version,
+			Source:  path.Base(s.Source.Path),
+			State:   string(s.State),
+		}
+		appliedAt := "Pending"
+		if s.State == goose.StateApplied {
+			t := s.AppliedAt.UTC()
+			m.AppliedAt = &t
+			appliedAt = t.Format("2006-01-02 15:04:05 MST")
+		}
+		fmt.Fprintf(&text, "\n%-25s %s", appliedAt, m.Source)
+		data = append(data, m)
+	}
+	return c.print(data, "%s", text.String())
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"io/fs"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/Specialized101/chirpy/sql/schema"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	db, err := sql.Open("postgres", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	provider, err := newMigrationProvider(db)
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+	sources := provider.ListSources()
+	if len(sources) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+
+	addColumn := regexp.MustCompile(`(?i)ADD COLUMN (\w+)`)
+	dropColumn := regexp.MustCompile(`(?i)DROP COLUMN (\w+)`)
+	for i, source := range sources {
+		if source.Version != int64(i+1) {
+			t.Errorf("%s: expected version %d, received %d", source.Path, i+1, source.Version)
+		}
+		data, err := fs.ReadFile(schema.FS, source.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		up, down, ok := strings.Cut(string(data), "-- +goose Down")
+		if !ok {
+			t.Errorf("%s: missing a Down section", source.Path)
+			continue
+		}
+		dropped := map[string]bool{}
+		for _, m := range dropColumn.FindAllStringSubmatch(down, -1) {
+			dropped[m[1]] = true
+		}
+		for _, m := range addColumn.FindAllStringSubmatch(up, -1) {
+			if !dropped[m[1]] {
+				t.Errorf("%s: column %s is added but never dropped", source.Path, m[1])
+			}
+		}
+	}
+}
+
+func TestRunMigrateUsage(t *testing.T) {
+	// sql.Open does not connect, the usage is checked before any query.
+	db, err := sql.Open("postgres", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	cases := []struct {
+		args     []string
+		expected string
+	}{
+		{args: nil, expected: "usage"},
+		{args: []string{"sideways"}, expected: "unknown migrate command"},
+		{args: []string{"up", "down"}, expected: "usage"},
+		{args: []string{"-json"}, expected: "usage"},
+	}
+	for _, c := range cases {
+		cmd, args, err := findCommand(append([]string{"migrate"}, c.args...))
+		if err != nil {
+			t.Fatalf("%q: %v", c.args, err)
+		}
+		cl := &cli{db: db, out: io.Discard}
+		run, args, err := cmd.parse(cl, args, envFrom(nil))
+		if err == nil {
+			err = run(context.Background(), cl, args)
+		}
+		if err == nil || !strings.Contains(err.Error(), c.expected) {
+			t.Errorf("%q: expected an error containing %q, received %v", c.args, c.expected, err)
+		}
+	}
+}
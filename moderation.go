@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/Specialized101/chirpy/internal/database"
+	"github.com/Specialized101/chirpy/internal/moderation"
+)
+
+// rejectedChirpMessage does not name the words, so it does not help finding
+// a spelling that gets through.
+const rejectedChirpMessage = "Chirp uses words that are not allowed"
+
+// loadModerationRules reads the word lists again and puts them in force: the
+// file, then the moderation_rules table, which wins for words in both. The
+// rules in force are kept when either cannot be read.
+func (cfg *apiConfig) loadModerationRules(ctx context.Context) error {
+	var rules []moderation.Rule
+	if cfg.moderationWordsFile != "" {
+		f, err := os.Open(cfg.moderationWordsFile)
+		if err != nil {
+			return fmt.Errorf("failed to open the moderation words file: %w", err)
+		}
+		defer f.Close()
+		rules, err = moderation.ParseRules(f)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", cfg.moderationWordsFile, err)
+		}
+	}
+	stored, err := cfg.db.GetModerationRules(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the moderation rules: %w", err)
+	}
+	for _, r := range stored {
+		rules = append(rules, moderation.Rule{Word: r.Word, Action: moderation.Action(r.Action)})
+	}
+	cfg.moderation.Replace(rules)
+	return nil
+}
+
+// reloadModerationRules calls loadModerationRules every interval until ctx
+// is done. A failed reload is logged and the previous rules stay in force.
+func (cfg *apiConfig) reloadModerationRules(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := cfg.loadModerationRules(ctx); err != nil {
+				log.Printf("failed to reload the moderation rules: %v", err)
+			}
+		}
+	}
+}
+
+// flagChirp puts a chirp up for review when it uses flagged words. Like
+// indexChirp, it runs once the chirp is saved and only logs failures.
+func (cfg *apiConfig) flagChirp(ctx context.Context, chirp database.Chirp, checked moderation.Result) {
+	words := checked.Words(moderation.Flag)
+	if len(words) == 0 {
+		return
+	}
+	err := cfg.db.FlagChirp(ctx, database.FlagChirpParams{
+		ChirpID:   chirp.ID,
+		Words:     words,
+		CreatedAt: chirp.UpdatedAt,
+	})
+	if err != nil {
+		log.Printf("failed to flag chirp %s: %v", chirp.ID, err)
+	}
+}
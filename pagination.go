@@ -0,0 +1,132 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 100
+)
+
+// pageCursor points at the last row of a page. The next page starts right
+// after it in (created_at, id) order.
+type pageCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+func encodeCursor(c pageCursor) string {
+	return encodeKeyset(c.CreatedAt.UTC().Format(time.RFC3339Nano), c.ID)
+}
+
+func decodeCursor(s string) (pageCursor, error) {
+	createdAt, id, err := decodeKeyset(s)
+	if err != nil {
+		return pageCursor{}, err
+	}
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return pageCursor{}, fmt.Errorf("cursor is not valid")
+	}
+	return pageCursor{CreatedAt: t, ID: id}, nil
+}
+
+// encodeKeyset makes an opaque cursor out of the sort key of the last row of
+// a page and its id. Lists that are not in (created_at, id) order use it to
+// keep the same cursors as the others.
+func encodeKeyset(key string, id uuid.UUID) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(key + "|" + id.String()))
+}
+
+func decodeKeyset(s string) (string, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return "", uuid.UUID{}, fmt.Errorf("cursor is not valid")
+	}
+	key, rawID, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return "", uuid.UUID{}, fmt.Errorf("cursor is not valid")
+	}
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return "", uuid.UUID{}, fmt.Errorf("cursor is not valid")
+	}
+	return key, id, nil
+}
+
+// pageParams holds the parsed limit and cursor query parameters. Cursor is
+// nil when the client asks for the first page.
+type pageParams struct {
+	Limit  int
+	Cursor *pageCursor
+}
+
+func parsePageParams(r *http.Request) (pageParams, error) {
+	limit, err := parsePageLimit(r)
+	if err != nil {
+		return pageParams{}, err
+	}
+	params := pageParams{Limit: limit}
+	if s := r.URL.Query().Get("cursor"); s != "" {
+		c, err := decodeCursor(s)
+		if err != nil {
+			return pageParams{}, err
+		}
+		params.Cursor = &c
+	}
+	return params, nil
+}
+
+// parsePageLimit parses the limit query parameter alone, for the lists whose
+// cursor is not a pageCursor.
+func parsePageLimit(r *http.Request) (int, error) {
+	s := r.URL.Query().Get("limit")
+	if s == "" {
+		return defaultPageLimit, nil
+	}
+	limit, err := strconv.Atoi(s)
+	if err != nil || limit < 1 || limit > maxPageLimit {
+		return 0, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+	}
+	return limit, nil
+}
+
+// queryLimit is the LIMIT to pass to the database. One extra row tells us
+// whether there is a next page.
+func (p pageParams) queryLimit() int32 {
+	return int32(p.Limit + 1)
+}
+
+// cursorArgs converts the cursor into the nullable query arguments used by the
+// keyset queries. Both are NULL on the first page.
+func (p pageParams) cursorArgs() (sql.NullTime, uuid.NullUUID) {
+	if p.Cursor == nil {
+		return sql.NullTime{}, uuid.NullUUID{}
+	}
+	return sql.NullTime{Time: p.Cursor.CreatedAt, Valid: true},
+		uuid.NullUUID{UUID: p.Cursor.ID, Valid: true}
+}
+
+// setNextLink advertises the next page in a Link header. The other query
+// parameters of the request are kept so filters carry over to the next page.
+func setNextLink(w http.ResponseWriter, r *http.Request, next pageCursor) {
+	setNextPageLink(w, r, encodeCursor(next))
+}
+
+// setNextPageLink is setNextLink for a cursor that is already encoded.
+func setNextPageLink(w http.ResponseWriter, r *http.Request, cursor string) {
+	q := r.URL.Query()
+	q.Set("cursor", cursor)
+	u := *r.URL
+	u.RawQuery = q.Encode()
+	w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, u.RequestURI()))
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	expected := pageCursor{
+		CreatedAt: time.Date(2025, 3, 14, 15, 9, 26, 535897000, time.UTC),
+		ID:        uuid.New(),
+	}
+	actual, err := decodeCursor(encodeCursor(expected))
+	if err != nil {
+		t.Fatalf("failed to decode cursor: %v", err)
+	}
+	if !actual.CreatedAt.Equal(expected.CreatedAt) || actual.ID != expected.ID {
+		t.Errorf("expected: %v\nreceived: %v", expected, actual)
+	}
+}
+
+func TestParsePageParams(t *testing.T) {
+	cases := []struct {
+		query     string
+		limit     int
+		hasCursor bool
+		wantErr   bool
+	}{
+		{query: "", limit: defaultPageLimit},
+		{query: "limit=10", limit: 10},
+		{query: "limit=0", wantErr: true},
+		{query: "limit=101", wantErr: true},
+		{query: "limit=abc", wantErr: true},
+		{query: "cursor=not-a-cursor", wantErr: true},
+		{
+			query:     "cursor=" + encodeCursor(pageCursor{CreatedAt: time.Now(), ID: uuid.New()}),
+			limit:     defaultPageLimit,
+			hasCursor: true,
+		},
+	}
+
+	for _, c := range cases {
+		r := httptest.NewRequest("GET", "/api/chirps?"+c.query, nil)
+		actual, err := parsePageParams(r)
+		if (err != nil) != c.wantErr {
+			t.Errorf("query %q: unexpected error: %v", c.query, err)
+			continue
+		}
+		if c.wantErr {
+			continue
+		}
+		if actual.Limit != c.limit || (actual.Cursor != nil) != c.hasCursor {
+			t.Errorf("query %q: received %+v", c.query, actual)
+		}
+	}
+}
+
+func TestSetNextLinkKeepsQuery(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/chirps?limit=5", nil)
+	w := httptest.NewRecorder()
+	next := pageCursor{CreatedAt: time.Now().UTC(), ID: uuid.New()}
+	setNextLink(w, r, next)
+	expected := `</api/chirps?cursor=` + encodeCursor(next) + `&limit=5>; rel="next"`
+	if actual := w.Header().Get("Link"); actual != expected {
+		t.Errorf("expected: %v\nreceived: %v", expected, actual)
+	}
+}
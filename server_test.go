@@ -0,0 +1,137 @@
+package main
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Specialized101/chirpy/internal/config"
+)
+
+// startServer runs serveUntilSignal on a free port with the timeouts of c.
+// /slow blocks until release is closed.
+func startServer(t *testing.T, c config.Config, release chan struct{}) (string, chan struct{}, chan error) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	started := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /ping", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("GET /slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		_, _ = io.WriteString(w, "done")
+	})
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: c.Server.ReadHeaderTimeout,
+		ReadTimeout:       c.Server.ReadTimeout,
+		WriteTimeout:      c.Server.WriteTimeout,
+		IdleTimeout:       c.Server.IdleTimeout,
+	}
+	served := make(chan error, 1)
+	go func() {
+		served <- serveUntilSignal(server, c.Server.ShutdownTimeout)
+	}()
+
+	// The signal handler is installed before the server listens.
+	for i := 0; ; i++ {
+		resp, err := http.Get("http://" + addr + "/ping")
+		if err == nil {
+			resp.Body.Close()
+			break
+		}
+		if i == 50 {
+			t.Fatalf("server did not start: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return addr, started, served
+}
+
+// interrupt sends the test process the signal a user would with Ctrl+C.
+func interrupt(t *testing.T) {
+	t.Helper()
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Signal(os.Interrupt); err != nil {
+		t.Skipf("cannot signal the test process: %v", err)
+	}
+}
+
+func TestServeUntilSignalDrains(t *testing.T) {
+	release := make(chan struct{})
+	addr, started, served := startServer(t, config.Default(), release)
+
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr + "/slow")
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		body <- string(b)
+	}()
+	<-started
+	interrupt(t)
+
+	// The in-flight request finishes, new connections are refused.
+	for i := 0; ; i++ {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			break
+		}
+		conn.Close()
+		if i == 50 {
+			t.Fatalf("expected the server to stop accepting connections")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	close(release)
+	if b := <-body; b != "done" {
+		t.Errorf("expected the in-flight request to finish, received %q", b)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestServeUntilSignalShutdownTimeout(t *testing.T) {
+	c := config.Default()
+	c.Server.ShutdownTimeout = 50 * time.Millisecond
+	release := make(chan struct{})
+	defer close(release)
+	addr, started, served := startServer(t, c, release)
+
+	go func() {
+		resp, err := http.Get("http://" + addr + "/slow")
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-started
+	interrupt(t)
+
+	select {
+	case err := <-served:
+		if err == nil || !strings.Contains(err.Error(), "failed to drain connections") {
+			t.Errorf("expected the drain to time out, received %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected serveUntilSignal to give up after %s", c.Server.ShutdownTimeout)
+	}
+}
@@ -0,0 +1,35 @@
+-- name: CreateBlock :exec
+-- CreateBlock also removes the follows between the two users, both ways.
+WITH unfollowed AS (
+    DELETE FROM follows
+    WHERE (follower_id = sqlc.arg(blocker_id) AND followee_id = sqlc.arg(blocked_id))
+       OR (follower_id = sqlc.arg(blocked_id) AND followee_id = sqlc.arg(blocker_id))
+)
+INSERT INTO blocks (blocker_id, blocked_id, created_at)
+VALUES (sqlc.arg(blocker_id), sqlc.arg(blocked_id), sqlc.arg(created_at))
+ON CONFLICT (blocker_id, blocked_id) DO NOTHING;
+
+-- name: DeleteBlock :exec
+DELETE FROM blocks
+WHERE blocker_id = $1
+  AND blocked_id = $2;
+
+-- name: IsBlocked :one
+SELECT EXISTS (
+    SELECT 1
+    FROM blocks
+    WHERE blocker_id = $1
+      AND blocked_id = $2
+);
+
+-- name: CreateMute :exec
+INSERT INTO mutes
+(muter_id, muted_id, created_at)
+VALUES
+($1, $2, $3)
+ON CONFLICT (muter_id, muted_id) DO NOTHING;
+
+-- name: DeleteMute :exec
+DELETE FROM mutes
+WHERE muter_id = $1
+  AND muted_id = $2;
\ No newline at end of file
@@ -0,0 +1,58 @@
+-- name: CreateChirpLike :exec
+INSERT INTO chirp_likes
+(user_id, chirp_id, created_at)
+VALUES
+($1, $2, $3)
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: DeleteChirpLike :exec
+DELETE FROM chirp_likes
+WHERE user_id = $1
+  AND chirp_id = $2;
+
+-- name: GetChirpLikes :many
+SELECT user_id, created_at
+FROM chirp_likes
+WHERE chirp_id = sqlc.arg(chirp_id)
+  AND (sqlc.narg(before_created_at)::timestamp IS NULL
+       OR (created_at, user_id) < (sqlc.narg(before_created_at)::timestamp, sqlc.narg(before_id)::uuid))
+ORDER BY created_at DESC, user_id DESC
+LIMIT sqlc.arg(page_limit);
+
+-- name: GetUserLikes :many
+-- GetUserLikes leaves out the chirps of the users viewer_id muted.
+SELECT sqlc.embed(chirps), chirp_likes.created_at AS liked_at
+FROM chirp_likes
+JOIN chirps ON chirps.id = chirp_likes.chirp_id
+WHERE chirp_likes.user_id = sqlc.arg(user_id)
+  AND chirps.deleted_at IS NULL
+  AND NOT EXISTS (
+    SELECT 1
+    FROM users
+    WHERE users.id = chirps.user_id
+      AND users.chirps_hidden
+      AND (users.suspended_until IS NULL OR users.suspended_until > NOW())
+  )
+  AND (sqlc.narg(before_created_at)::timestamp IS NULL
+       OR (chirp_likes.created_at, chirp_likes.chirp_id) < (sqlc.narg(before_created_at)::timestamp, sqlc.narg(before_id)::uuid))
+  AND NOT EXISTS (
+    SELECT 1
+    FROM mutes
+    WHERE mutes.muter_id = sqlc.narg(viewer_id)::uuid
+      AND mutes.muted_id = chirps.user_id
+  )
+ORDER BY chirp_likes.created_at DESC, chirp_likes.chirp_id DESC
+LIMIT sqlc.arg(page_limit);
+
+-- name: GetLikeCounts :many
+SELECT chirp_id, COUNT(*) AS like_count
+FROM chirp_likes
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+GROUP BY chirp_id;
+
+-- name: GetLikedChirpIDs :many
+-- GetLikedChirpIDs returns which of the given chirps the user likes.
+SELECT chirp_id
+FROM chirp_likes
+WHERE user_id = sqlc.arg(user_id)
+  AND chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]);
\ No newline at end of file
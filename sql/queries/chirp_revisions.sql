@@ -0,0 +1,8 @@
+-- name: GetChirpRevisions :many
+SELECT *
+FROM chirp_revisions
+WHERE chirp_id = sqlc.arg(chirp_id)
+  AND (sqlc.narg(before_replaced_at)::timestamp IS NULL
+       OR (replaced_at, id) < (sqlc.narg(before_replaced_at)::timestamp, sqlc.narg(before_id)::uuid))
+ORDER BY replaced_at DESC, id DESC
+LIMIT sqlc.arg(page_limit);
\ No newline at end of file
@@ -1,20 +1,247 @@
 -- name: CreateChirp :one
 INSERT INTO chirps
-(id, created_at, updated_at, body, user_id)
+(id, created_at, updated_at, body, user_id, parent_id, quote_of_id)
 VALUES
-($1, $2, $3, $4, $5)
+($1, $2, $3, $4, $5, $6, $7)
 RETURNING *;
 
+-- name: CreateRechirp :one
+-- CreateRechirp returns the existing rechirp when the user already rechirped
+-- the chirp, so there is at most one per user and chirp.
+INSERT INTO chirps
+(id, created_at, updated_at, body, user_id, rechirp_of_id)
+VALUES
+(sqlc.arg(id), sqlc.arg(created_at), sqlc.arg(created_at), '', sqlc.arg(user_id), sqlc.arg(chirp_id)::uuid)
+ON CONFLICT (user_id, rechirp_of_id) WHERE rechirp_of_id IS NOT NULL
+DO UPDATE SET updated_at = chirps.updated_at
+RETURNING *;
+
+-- name: DeleteRechirp :exec
+DELETE FROM chirps
+WHERE user_id = sqlc.arg(user_id)
+  AND rechirp_of_id = sqlc.arg(chirp_id)::uuid;
+
 -- name: GetChirps :many
+-- GetChirps leaves out the chirps of the users suspended with their chirps
+-- hidden and, unless they are asked for with author_id, the chirps of the
+-- users viewer_id muted.
 SELECT *
 FROM chirps
-ORDER BY created_at ASC;
+WHERE deleted_at IS NULL
+  AND (sqlc.narg(author_id)::uuid IS NULL OR user_id = sqlc.narg(author_id)::uuid)
+  AND (sqlc.narg(after_created_at)::timestamp IS NULL
+       OR (created_at, id) > (sqlc.narg(after_created_at)::timestamp, sqlc.narg(after_id)::uuid))
+  AND NOT EXISTS (
+    SELECT 1
+    FROM users
+    WHERE users.id = chirps.user_id
+      AND users.chirps_hidden
+      AND (users.suspended_until IS NULL OR users.suspended_until > NOW())
+  )
+  AND (sqlc.narg(author_id)::uuid IS NOT NULL OR NOT EXISTS (
+    SELECT 1
+    FROM mutes
+    WHERE mutes.muter_id = sqlc.narg(viewer_id)::uuid
+      AND mutes.muted_id = chirps.user_id
+  ))
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg(page_limit);
+
+-- name: GetChirpsDesc :many
+-- GetChirpsDesc is GetChirps, newest first.
+SELECT *
+FROM chirps
+WHERE deleted_at IS NULL
+  AND (sqlc.narg(author_id)::uuid IS NULL OR user_id = sqlc.narg(author_id)::uuid)
+  AND (sqlc.narg(before_created_at)::timestamp IS NULL
+       OR (created_at, id) < (sqlc.narg(before_created_at)::timestamp, sqlc.narg(before_id)::uuid))
+  AND NOT EXISTS (
+    SELECT 1
+    FROM users
+    WHERE users.id = chirps.user_id
+      AND users.chirps_hidden
+      AND (users.suspended_until IS NULL OR users.suspended_until > NOW())
+  )
+  AND (sqlc.narg(author_id)::uuid IS NOT NULL OR NOT EXISTS (
+    SELECT 1
+    FROM mutes
+    WHERE mutes.muter_id = sqlc.narg(viewer_id)::uuid
+      AND mutes.muted_id = chirps.user_id
+  ))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_limit);
+
+-- name: SearchChirps :many
+-- SearchChirps ranks the chirps matching a web search style query, best
+-- first, leaving out the chirps of the users viewer_id muted. Headline is the
+-- whole body with every match between a \x02 and a \x03, markers a chirp is
+-- unlikely to contain.
+SELECT sqlc.embed(chirps),
+       ts_rank(chirps.search_vector, search) AS rank,
+       ts_headline('english', chirps.body, search,
+                   'HighlightAll=true, StartSel=' || chr(2) || ', StopSel=' || chr(3)) AS headline
+FROM chirps, websearch_to_tsquery('english', sqlc.arg(query)) AS search
+WHERE chirps.search_vector @@ search
+  AND chirps.deleted_at IS NULL
+  AND NOT EXISTS (
+    SELECT 1
+    FROM users
+    WHERE users.id = chirps.user_id
+      AND users.chirps_hidden
+      AND (users.suspended_until IS NULL OR users.suspended_until > NOW())
+  )
+  AND (sqlc.narg(before_rank)::real IS NULL
+       OR (ts_rank(chirps.search_vector, search), chirps.id) < (sqlc.narg(before_rank)::real, sqlc.narg(before_id)::uuid))
+  AND NOT EXISTS (
+    SELECT 1
+    FROM mutes
+    WHERE mutes.muter_id = sqlc.narg(viewer_id)::uuid
+      AND mutes.muted_id = chirps.user_id
+  )
+ORDER BY rank DESC, chirps.id DESC
+LIMIT sqlc.arg(page_limit);
+
+-- name: GetChirpsByIDs :many
+-- GetChirpsByIDs includes deleted chirps, to show them as tombstones.
+SELECT *
+FROM chirps
+WHERE id = ANY(sqlc.arg(ids)::uuid[]);
 
 -- name: GetChirpByID :one
 SELECT *
 FROM chirps
-WHERE id = $1;
+WHERE id = $1
+  AND deleted_at IS NULL;
+
+-- name: UpdateChirp :one
+-- UpdateChirp replaces the body of a chirp and keeps the previous one as a
+-- revision, in a single statement so an edit is never half applied.
+WITH previous AS (
+    INSERT INTO chirp_revisions (id, chirp_id, body, replaced_at)
+    SELECT sqlc.arg(revision_id), id, body, sqlc.arg(updated_at)
+    FROM chirps
+    WHERE id = sqlc.arg(id)
+)
+UPDATE chirps
+SET body = sqlc.arg(body), updated_at = sqlc.arg(updated_at)
+WHERE id = sqlc.arg(id)
+RETURNING *;
 
 -- name: DeleteChirpByID :exec
 DELETE FROM chirps
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: TombstoneChirp :exec
+-- TombstoneChirp blanks a chirp instead of deleting it, so the replies keep
+-- their place in the thread. Its revisions, likes, hashtags, mentions, flags
+-- and plain rechirps go, like on a real delete.
+WITH revisions AS (
+    DELETE FROM chirp_revisions
+    WHERE chirp_id = sqlc.arg(id)
+), likes AS (
+    DELETE FROM chirp_likes
+    WHERE chirp_id = sqlc.arg(id)
+), hashtags AS (
+    DELETE FROM chirp_hashtags
+    WHERE chirp_id = sqlc.arg(id)
+), mentions AS (
+    DELETE FROM mentions
+    WHERE chirp_id = sqlc.arg(id)
+), flags AS (
+    DELETE FROM chirp_flags
+    WHERE chirp_id = sqlc.arg(id)
+), rechirps AS (
+    DELETE FROM chirps
+    WHERE rechirp_of_id = sqlc.arg(id)
+)
+UPDATE chirps
+SET body = '', deleted_at = sqlc.arg(deleted_at), updated_at = sqlc.arg(deleted_at)
+WHERE id = sqlc.arg(id);
+
+-- name: GetTimeline :many
+-- GetTimeline leaves out the chirps of the followed users user_id muted.
+SELECT chirps.*
+FROM chirps
+JOIN follows ON follows.followee_id = chirps.user_id
+WHERE follows.follower_id = sqlc.arg(user_id)
+  AND chirps.deleted_at IS NULL
+  AND NOT EXISTS (
+    SELECT 1
+    FROM users
+    WHERE users.id = chirps.user_id
+      AND users.chirps_hidden
+      AND (users.suspended_until IS NULL OR users.suspended_until > NOW())
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM mutes
+    WHERE mutes.muter_id = sqlc.arg(user_id)
+      AND mutes.muted_id = chirps.user_id
+  )
+  AND (sqlc.narg(before_created_at)::timestamp IS NULL
+       OR (chirps.created_at, chirps.id) < (sqlc.narg(before_created_at)::timestamp, sqlc.narg(before_id)::uuid))
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg(page_limit);
+
+-- name: GetChirpPath :many
+-- GetChirpPath returns a chirp and the chirps it replies to, up to the root
+-- of the thread, root first. Deleted chirps are included as tombstones.
+WITH RECURSIVE path AS (
+    SELECT chirps.id, chirps.parent_id
+    FROM chirps
+    WHERE chirps.id = sqlc.arg(id)
+    UNION ALL
+    SELECT chirps.id, chirps.parent_id
+    FROM chirps
+    JOIN path ON chirps.id = path.parent_id
+)
+SELECT chirps.*
+FROM chirps
+JOIN path ON path.id = chirps.id
+ORDER BY chirps.created_at ASC, chirps.id ASC;
+
+-- name: GetChirpDescendants :many
+-- GetChirpDescendants returns every reply under a chirp, oldest first, so a
+-- reply always comes after the chirp it replies to.
+WITH RECURSIVE descendants AS (
+    SELECT chirps.id
+    FROM chirps
+    WHERE chirps.parent_id = sqlc.arg(chirp_id)
+    UNION ALL
+    SELECT chirps.id
+    FROM chirps
+    JOIN descendants ON chirps.parent_id = descendants.id
+)
+SELECT chirps.*
+FROM chirps
+JOIN descendants ON descendants.id = chirps.id
+WHERE (sqlc.narg(after_created_at)::timestamp IS NULL
+       OR (chirps.created_at, chirps.id) > (sqlc.narg(after_created_at)::timestamp, sqlc.narg(after_id)::uuid))
+ORDER BY chirps.created_at ASC, chirps.id ASC
+LIMIT sqlc.arg(page_limit);
+
+-- name: GetReplyCounts :many
+SELECT parent_id::uuid AS chirp_id, COUNT(*) AS reply_count
+FROM chirps
+WHERE parent_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+  AND deleted_at IS NULL
+GROUP BY parent_id;
+
+-- name: HasReplies :one
+-- HasReplies counts deleted replies too, unlike GetReplyCounts: a tombstone
+-- still holds its own replies in the thread.
+SELECT EXISTS (
+    SELECT 1
+    FROM chirps
+    WHERE parent_id = sqlc.arg(chirp_id)::uuid
+);
+
+-- name: GetRepostCounts :many
+SELECT COALESCE(rechirp_of_id, quote_of_id)::uuid AS chirp_id,
+       COUNT(rechirp_of_id) AS rechirp_count,
+       COUNT(quote_of_id) AS quote_count
+FROM chirps
+WHERE (rechirp_of_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+       OR quote_of_id = ANY(sqlc.arg(chirp_ids)::uuid[]))
+  AND deleted_at IS NULL
+GROUP BY COALESCE(rechirp_of_id, quote_of_id);
\ No newline at end of file
@@ -0,0 +1,51 @@
+-- name: SetChirpHashtags :exec
+-- SetChirpHashtags replaces the hashtags of a chirp with tags, creating the
+-- hashtags used for the first time. Tags the chirp already had keep the time
+-- they were added, which is what trending ranks by.
+WITH new_hashtags AS (
+    INSERT INTO hashtags (tag, created_at)
+    SELECT unnest(sqlc.arg(tags)::text[]), sqlc.arg(tagged_at)
+    ON CONFLICT (tag) DO NOTHING
+), removed AS (
+    DELETE FROM chirp_hashtags
+    WHERE chirp_id = sqlc.arg(chirp_id)
+      AND NOT (tag = ANY(sqlc.arg(tags)::text[]))
+)
+INSERT INTO chirp_hashtags (chirp_id, tag, created_at)
+SELECT sqlc.arg(chirp_id), unnest(sqlc.arg(tags)::text[]), sqlc.arg(tagged_at)
+ON CONFLICT (chirp_id, tag) DO NOTHING;
+
+-- name: GetHashtagChirps :many
+-- GetHashtagChirps leaves out the chirps of the users viewer_id muted.
+SELECT chirps.*
+FROM chirps
+JOIN chirp_hashtags ON chirp_hashtags.chirp_id = chirps.id
+WHERE chirp_hashtags.tag = sqlc.arg(tag)
+  AND chirps.deleted_at IS NULL
+  AND NOT EXISTS (
+    SELECT 1
+    FROM users
+    WHERE users.id = chirps.user_id
+      AND users.chirps_hidden
+      AND (users.suspended_until IS NULL OR users.suspended_until > NOW())
+  )
+  AND (sqlc.narg(before_created_at)::timestamp IS NULL
+       OR (chirps.created_at, chirps.id) < (sqlc.narg(before_created_at)::timestamp, sqlc.narg(before_id)::uuid))
+  AND NOT EXISTS (
+    SELECT 1
+    FROM mutes
+    WHERE mutes.muter_id = sqlc.narg(viewer_id)::uuid
+      AND mutes.muted_id = chirps.user_id
+  )
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg(page_limit);
+
+-- name: GetTrendingHashtags :many
+-- GetTrendingHashtags ranks the tags by the number of chirps they were added
+-- to since the given time.
+SELECT tag, COUNT(*) AS chirp_count
+FROM chirp_hashtags
+WHERE created_at >= sqlc.arg(since)
+GROUP BY tag
+ORDER BY chirp_count DESC, tag ASC
+LIMIT sqlc.arg(page_limit);
\ No newline at end of file
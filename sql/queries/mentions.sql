@@ -0,0 +1,48 @@
+-- name: SetChirpMentions :exec
+-- SetChirpMentions replaces the users a chirp mentions with the owners of
+-- handles. Handles nobody owns, or whose owner blocked author_id, are left
+-- out, they stay plain text.
+WITH mentioned AS (
+    SELECT id
+    FROM users
+    WHERE lower(username) = ANY(sqlc.arg(handles)::text[])
+      AND NOT EXISTS (
+        SELECT 1
+        FROM blocks
+        WHERE blocks.blocker_id = users.id
+          AND blocks.blocked_id = sqlc.arg(author_id)
+      )
+), removed AS (
+    DELETE FROM mentions
+    WHERE chirp_id = sqlc.arg(chirp_id)
+      AND user_id NOT IN (SELECT id FROM mentioned)
+)
+INSERT INTO mentions (chirp_id, user_id, created_at)
+SELECT sqlc.arg(chirp_id), id, sqlc.arg(created_at)
+FROM mentioned
+ON CONFLICT (chirp_id, user_id) DO NOTHING;
+
+-- name: GetUserMentions :many
+-- GetUserMentions leaves out the chirps of the users user_id muted.
+SELECT chirps.*
+FROM chirps
+JOIN mentions ON mentions.chirp_id = chirps.id
+WHERE mentions.user_id = sqlc.arg(user_id)
+  AND chirps.deleted_at IS NULL
+  AND NOT EXISTS (
+    SELECT 1
+    FROM users
+    WHERE users.id = chirps.user_id
+      AND users.chirps_hidden
+      AND (users.suspended_until IS NULL OR users.suspended_until > NOW())
+  )
+  AND (sqlc.narg(before_created_at)::timestamp IS NULL
+       OR (chirps.created_at, chirps.id) < (sqlc.narg(before_created_at)::timestamp, sqlc.narg(before_id)::uuid))
+  AND NOT EXISTS (
+    SELECT 1
+    FROM mutes
+    WHERE mutes.muter_id = sqlc.arg(user_id)
+      AND mutes.muted_id = chirps.user_id
+  )
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg(page_limit);
\ No newline at end of file
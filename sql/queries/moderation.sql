@@ -0,0 +1,36 @@
+-- name: GetModerationRules :many
+SELECT *
+FROM moderation_rules
+ORDER BY word;
+
+-- name: UpsertModerationRule :one
+INSERT INTO moderation_rules (word, action, created_at, updated_at)
+VALUES (
+    $1, $2, $3, $3
+)
+ON CONFLICT (word) DO UPDATE SET action = EXCLUDED.action, updated_at = EXCLUDED.updated_at
+RETURNING *;
+
+-- name: DeleteModerationRule :one
+DELETE FROM moderation_rules
+WHERE word = $1
+RETURNING *;
+
+-- name: FlagChirp :exec
+-- FlagChirp puts a chirp up for review, again after an edit that still uses
+-- flagged words.
+INSERT INTO chirp_flags (chirp_id, words, created_at)
+VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (chirp_id) DO UPDATE SET words = EXCLUDED.words, created_at = EXCLUDED.created_at;
+
+-- name: GetFlaggedChirps :many
+SELECT sqlc.embed(chirps), chirp_flags.words, chirp_flags.created_at AS flagged_at
+FROM chirp_flags
+JOIN chirps ON chirps.id = chirp_flags.chirp_id
+WHERE chirps.deleted_at IS NULL
+  AND (sqlc.narg(before_flagged_at)::timestamp IS NULL
+       OR (chirp_flags.created_at, chirp_flags.chirp_id) < (sqlc.narg(before_flagged_at)::timestamp, sqlc.narg(before_id)::uuid))
+ORDER BY chirp_flags.created_at DESC, chirp_flags.chirp_id DESC
+LIMIT sqlc.arg(page_limit);
\ No newline at end of file
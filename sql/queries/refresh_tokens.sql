@@ -1,8 +1,8 @@
 -- name: CreateRefreshToken :one
 INSERT INTO refresh_tokens
-(token, created_at, updated_at, expires_at, revoked_at, user_id)
+(token, created_at, updated_at, expires_at, revoked_at, user_id, family_id)
 VALUES
-($1, $2, $3, $4, $5, $6)
+($1, $2, $3, $4, $5, $6, $7)
 RETURNING *;
 
 -- name: GetRefreshToken :one
@@ -10,13 +10,33 @@ SELECT *
 FROM refresh_tokens
 WHERE token = $1;
 
--- name: GetRefreshTokenByUserId :one
-SELECT *
-FROM refresh_tokens
-WHERE user_id = $1;
-
 -- name: RevokeRefreshToken :exec
 UPDATE refresh_tokens
 SET updated_at = NOW(),
     revoked_at = NOW()
-WHERE token = $1;
\ No newline at end of file
+WHERE token = $1;
+
+-- name: RotateRefreshToken :one
+-- Retires a token that is still active. No row is returned when the token
+-- has already been rotated or revoked, which means it is being reused.
+UPDATE refresh_tokens
+SET updated_at = NOW(),
+    rotated_at = NOW()
+WHERE token = $1
+  AND revoked_at IS NULL
+  AND rotated_at IS NULL
+RETURNING *;
+
+-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_tokens
+SET updated_at = NOW(),
+    revoked_at = NOW()
+WHERE family_id = $1
+  AND revoked_at IS NULL;
+
+-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens
+SET updated_at = NOW(),
+    revoked_at = NOW()
+WHERE user_id = $1
+  AND revoked_at IS NULL;
\ No newline at end of file
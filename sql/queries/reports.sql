@@ -0,0 +1,55 @@
+-- name: CreateReport :one
+INSERT INTO reports
+(id, created_at, updated_at, reporter_id, user_id, chirp_id, chirp_body, reason, details)
+VALUES
+($1, $2, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: GetReportByID :one
+SELECT *
+FROM reports
+WHERE id = $1;
+
+-- name: GetReports :many
+-- GetReports lists the reports with a status, oldest first: open ones are
+-- waiting for a moderator, claimed ones are being handled.
+SELECT *
+FROM reports
+WHERE CASE sqlc.arg(status)::text
+          WHEN 'open' THEN claimed_at IS NULL AND resolved_at IS NULL
+          WHEN 'claimed' THEN claimed_at IS NOT NULL AND resolved_at IS NULL
+          ELSE resolved_at IS NOT NULL
+      END
+  AND (sqlc.narg(after_created_at)::timestamp IS NULL
+       OR (created_at, id) > (sqlc.narg(after_created_at)::timestamp, sqlc.narg(after_id)::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg(page_limit);
+
+-- name: ClaimReport :one
+-- ClaimReport assigns an unresolved report to a moderator. No row is returned
+-- when the report is resolved or claimed by another moderator.
+UPDATE reports
+SET claimed_by = sqlc.arg(moderator_id)::uuid,
+    claimed_at = COALESCE(claimed_at, sqlc.arg(claimed_at)::timestamp),
+    updated_at = sqlc.arg(claimed_at)::timestamp
+WHERE id = sqlc.arg(id)
+  AND resolved_at IS NULL
+  AND (claimed_by IS NULL OR claimed_by = sqlc.arg(moderator_id)::uuid)
+RETURNING *;
+
+-- name: ResolveReport :one
+-- ResolveReport records the outcome of a report and the moderator who chose
+-- it, claiming it on the way if nobody did. Like ClaimReport, no row is
+-- returned when the report is resolved or claimed by another moderator.
+UPDATE reports
+SET claimed_by = sqlc.arg(moderator_id)::uuid,
+    claimed_at = COALESCE(claimed_at, sqlc.arg(resolved_at)::timestamp),
+    resolved_by = sqlc.arg(moderator_id)::uuid,
+    resolved_at = sqlc.arg(resolved_at)::timestamp,
+    resolution = sqlc.arg(resolution)::text,
+    resolution_note = sqlc.arg(resolution_note),
+    updated_at = sqlc.arg(resolved_at)::timestamp
+WHERE id = sqlc.arg(id)
+  AND resolved_at IS NULL
+  AND (claimed_by IS NULL OR claimed_by = sqlc.arg(moderator_id)::uuid)
+RETURNING *;
\ No newline at end of file
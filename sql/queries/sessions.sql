@@ -0,0 +1,33 @@
+-- name: CreateSession :one
+INSERT INTO sessions
+(id, created_at, updated_at, last_used_at, user_agent, ip_address, user_id)
+VALUES
+($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetSessionByID :one
+SELECT *
+FROM sessions
+WHERE id = $1;
+
+-- name: GetActiveSessionsByUserID :many
+SELECT *
+FROM sessions
+WHERE user_id = $1
+  AND EXISTS (
+    SELECT 1
+    FROM refresh_tokens
+    WHERE refresh_tokens.family_id = sessions.id
+      AND refresh_tokens.revoked_at IS NULL
+      AND refresh_tokens.rotated_at IS NULL
+      AND refresh_tokens.expires_at > NOW()
+  )
+ORDER BY last_used_at DESC;
+
+-- name: TouchSession :exec
+UPDATE sessions
+SET updated_at = NOW(),
+    last_used_at = NOW(),
+    user_agent = $2,
+    ip_address = $3
+WHERE id = $1;
\ No newline at end of file
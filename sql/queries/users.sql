@@ -1,7 +1,7 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, username)
 VALUES (
-    $1, $2, $3, $4, $5
+    $1, $2, $3, $4, $5, $6
 )
 RETURNING *;
 
@@ -13,12 +13,34 @@ SELECT *
 FROM users
 WHERE email = $1;
 
+-- name: GetUserByID :one
+SELECT *
+FROM users
+WHERE id = $1;
+
+-- name: GetUserByUsername :one
+SELECT *
+FROM users
+WHERE lower(username) = lower(sqlc.arg(username));
+
+-- name: SearchUsers :many
+-- SearchUsers lists the users whose username matches a LIKE pattern, which
+-- must be lower case, in username order.
+SELECT *
+FROM users
+WHERE lower(username) LIKE sqlc.arg(pattern)::text
+  AND (sqlc.narg(after_username)::text IS NULL OR lower(username) > sqlc.narg(after_username)::text)
+ORDER BY lower(username)
+LIMIT sqlc.arg(page_limit);
+
 -- name: UpdateUser :one
+-- UpdateUser keeps the username when username is NULL.
 UPDATE users
-SET email = $1,
-    hashed_password = $2,
+SET email = sqlc.arg(email),
+    hashed_password = sqlc.arg(hashed_password),
+    username = COALESCE(sqlc.narg(username), username),
     updated_at = NOW()
-WHERE id = $3
+WHERE id = sqlc.arg(id)
 RETURNING *;
 
 -- name: UpgradeUser :one
@@ -26,4 +48,34 @@ UPDATE users
 SET is_chirpy_red = TRUE,
     updated_at = NOW()
 WHERE id = $1
+RETURNING *;
+
+-- name: SetUserRole :one
+UPDATE users
+SET role = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: SuspendUser :one
+-- SuspendUser replaces the current suspension of the user, if any.
+UPDATE users
+SET suspended_at = sqlc.arg(suspended_at)::timestamp,
+    suspended_until = sqlc.narg(suspended_until),
+    suspension_reason = sqlc.arg(suspension_reason),
+    banned = sqlc.arg(banned),
+    chirps_hidden = sqlc.arg(chirps_hidden),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: LiftSuspension :one
+UPDATE users
+SET suspended_at = NULL,
+    suspended_until = NULL,
+    suspension_reason = '',
+    banned = FALSE,
+    chirps_hidden = FALSE,
+    updated_at = NOW()
+WHERE id = $1
 RETURNING *;
\ No newline at end of file
@@ -4,4 +4,4 @@ ADD COLUMN hashed_password TEXT NOT NULL;
 
 -- +goose Down
 ALTER TABLE users
-DROP COLUMN password;
\ No newline at end of file
+DROP COLUMN hashed_password;
\ No newline at end of file
@@ -0,0 +1,36 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN rechirp_of_id UUID REFERENCES chirps(id) ON DELETE CASCADE;
+
+ALTER TABLE chirps
+ADD COLUMN quote_of_id UUID REFERENCES chirps(id) ON DELETE SET NULL;
+
+ALTER TABLE chirps
+ADD CONSTRAINT chirps_rechirp_or_quote_check
+CHECK (rechirp_of_id IS NULL OR quote_of_id IS NULL);
+
+CREATE UNIQUE INDEX chirps_user_id_rechirp_of_id_idx
+ON chirps (user_id, rechirp_of_id)
+WHERE rechirp_of_id IS NOT NULL;
+
+CREATE INDEX chirps_rechirp_of_id_idx
+ON chirps (rechirp_of_id);
+
+CREATE INDEX chirps_quote_of_id_idx
+ON chirps (quote_of_id);
+
+-- +goose Down
+DROP INDEX chirps_quote_of_id_idx;
+
+DROP INDEX chirps_rechirp_of_id_idx;
+
+DROP INDEX chirps_user_id_rechirp_of_id_idx;
+
+ALTER TABLE chirps
+DROP CONSTRAINT chirps_rechirp_or_quote_check;
+
+ALTER TABLE chirps
+DROP COLUMN quote_of_id;
+
+ALTER TABLE chirps
+DROP COLUMN rechirp_of_id;
\ No newline at end of file
@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE hashtags (
+    tag TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE chirp_hashtags (
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    tag TEXT NOT NULL REFERENCES hashtags(tag) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (chirp_id, tag)
+);
+
+CREATE INDEX chirp_hashtags_tag_idx
+ON chirp_hashtags (tag);
+
+CREATE INDEX chirp_hashtags_created_at_idx
+ON chirp_hashtags (created_at);
+
+-- +goose Down
+DROP TABLE chirp_hashtags;
+
+DROP TABLE hashtags;
\ No newline at end of file
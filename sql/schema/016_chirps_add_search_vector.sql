@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN search_vector TSVECTOR
+GENERATED ALWAYS AS (to_tsvector('english', body)) STORED;
+
+CREATE INDEX chirps_search_vector_idx
+ON chirps USING GIN (search_vector);
+
+-- Usernames are searched by prefix, which the unique index cannot serve
+-- outside the C collation.
+CREATE INDEX users_username_pattern_idx
+ON users (lower(username) text_pattern_ops);
+
+-- +goose Down
+DROP INDEX users_username_pattern_idx;
+
+DROP INDEX chirps_search_vector_idx;
+
+ALTER TABLE chirps
+DROP COLUMN search_vector;
\ No newline at end of file
@@ -0,0 +1,28 @@
+-- +goose Up
+CREATE TABLE moderation_rules (
+    word TEXT PRIMARY KEY,
+    action TEXT NOT NULL CHECK (action IN ('mask', 'flag', 'reject')),
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+-- The words censored before the lists could be edited.
+INSERT INTO moderation_rules (word, action, created_at, updated_at)
+VALUES
+('kerfuffle', 'mask', NOW(), NOW()),
+('sharbert', 'mask', NOW(), NOW()),
+('fornax', 'mask', NOW(), NOW());
+
+CREATE TABLE chirp_flags (
+    chirp_id UUID PRIMARY KEY REFERENCES chirps(id) ON DELETE CASCADE,
+    words TEXT[] NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX chirp_flags_created_at_idx
+ON chirp_flags (created_at);
+
+-- +goose Down
+DROP TABLE chirp_flags;
+
+DROP TABLE moderation_rules;
\ No newline at end of file
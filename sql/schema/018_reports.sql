@@ -0,0 +1,33 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN suspended_at TIMESTAMP;
+
+-- A report is about a user, and about one of their chirps when chirp_id
+-- is set. chirp_body keeps the chirp as it was reported, so the evidence
+-- outlives an edit or a delete.
+CREATE TABLE reports (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    reporter_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID REFERENCES chirps(id) ON DELETE SET NULL,
+    chirp_body TEXT,
+    reason TEXT NOT NULL CHECK (reason IN ('spam', 'harassment', 'hate', 'violence', 'sexual_content', 'self_harm', 'impersonation', 'other')),
+    details TEXT NOT NULL,
+    claimed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    claimed_at TIMESTAMP,
+    resolved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMP,
+    resolution TEXT CHECK (resolution IN ('dismiss', 'hide_chirp', 'suspend_user')),
+    resolution_note TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX reports_created_at_idx
+ON reports (created_at);
+
+-- +goose Down
+DROP TABLE reports;
+
+ALTER TABLE users
+DROP COLUMN suspended_at;
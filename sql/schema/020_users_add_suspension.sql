@@ -0,0 +1,27 @@
+-- +goose Up
+-- A user is suspended from suspended_at until suspended_until, or until the
+-- suspension is lifted when it is NULL. A ban is a suspension too.
+ALTER TABLE users
+ADD COLUMN suspended_until TIMESTAMP;
+
+ALTER TABLE users
+ADD COLUMN suspension_reason TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE users
+ADD COLUMN banned BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE users
+ADD COLUMN chirps_hidden BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN chirps_hidden;
+
+ALTER TABLE users
+DROP COLUMN banned;
+
+ALTER TABLE users
+DROP COLUMN suspension_reason;
+
+ALTER TABLE users
+DROP COLUMN suspended_until;
\ No newline at end of file
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Specialized101/chirpy/internal/database"
+	"github.com/Specialized101/chirpy/internal/handle"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// parseUsername validates the optional username of a request. No username
+// gives a NULL string.
+func parseUsername(username *string) (sql.NullString, error) {
+	if username == nil {
+		return sql.NullString{}, nil
+	}
+	if err := handle.Validate(*username); err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: *username, Valid: true}, nil
+}
+
+// usernameTaken reports whether err comes from the unique index on
+// usernames, whatever their case.
+func usernameTaken(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "users_username_key"
+}
+
+// nullableString is s for JSON, null when it is NULL.
+func nullableString(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+// nullableUUID is u for JSON, null when it is NULL.
+func nullableUUID(u uuid.NullUUID) *uuid.UUID {
+	if !u.Valid {
+		return nil
+	}
+	return &u.UUID
+}
+
+// nullableTime is t for JSON, null when it is NULL.
+func nullableTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// userSuspended reports whether the user is suspended or banned right now. A
+// suspension with an end is over once suspended_until passes.
+func userSuspended(user database.User) bool {
+	return user.SuspendedAt.Valid && (!user.SuspendedUntil.Valid || user.SuspendedUntil.Time.After(time.Now().UTC()))
+}
+
+// suspensionMessage tells a suspended user until when and why.
+func suspensionMessage(user database.User) string {
+	msg := "account is suspended"
+	if user.Banned {
+		msg = "account is banned"
+	}
+	if user.SuspendedUntil.Valid {
+		msg += " until " + user.SuspendedUntil.Time.Format(time.RFC3339)
+	}
+	if user.SuspensionReason != "" {
+		msg += ": " + user.SuspensionReason
+	}
+	return msg
+}